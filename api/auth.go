@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// API scopes, admin includes read
+const (
+	ScopeRead  = "read"
+	ScopeAdmin = "admin"
+)
+
+// API keys live in config as "apiKey.<name>" = "<scope>:<sha256 of the key>", the key itself is never stored
+const APIKeyPrefix = "apiKey."
+
+// Basic auth password is stored as "pbkdf2-sha256$<iterations>$<salt>$<hash>"
+const passwordIterations = 210000
+
+// verifiedPasswords remembers checked basic auth credentials by their hash with the stored password,
+// PBKDF2 is too slow to run on every request
+var (
+	verifiedMu        sync.Mutex
+	verifiedPasswords = make(map[string]bool)
+)
+
+// NewAPIKey generates a random API key
+func NewAPIKey() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return "tut_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidScope tells whether scope is read or admin
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeAdmin
+}
+
+// PutAPIKey stores the hash of an API key under name
+func PutAPIKey(tx storage.Tx, name string, scope string, key string) error {
+	if !ValidScope(scope) {
+		return fmt.Errorf("PutAPIKey: unknown scope %q, use read or admin", scope)
+	}
+	return tx.SetConfig(APIKeyPrefix+name, scope+":"+hashAPIKey(key))
+}
+
+// APIKeyScopes lists API key names with their scope
+func APIKeyScopes(tx storage.Tx) map[string]string {
+	keys := make(map[string]string)
+	tx.ForEachConfig(func(k string, v string) error {
+		if strings.HasPrefix(k, APIKeyPrefix) && v != "" {
+			keys[strings.TrimPrefix(k, APIKeyPrefix)] = strings.SplitN(v, ":", 2)[0]
+		}
+		return nil
+	})
+	return keys
+}
+
+// apiKeyScope finds the name and scope of an API key, empty if the key is unknown
+func apiKeyScope(tx storage.Tx, key string) (string, string) {
+	hash := []byte(hashAPIKey(key))
+	var name, scope string
+	tx.ForEachConfig(func(k string, v string) error {
+		parts := strings.SplitN(v, ":", 2)
+		if strings.HasPrefix(k, APIKeyPrefix) && len(parts) == 2 && subtle.ConstantTimeCompare(hash, []byte(parts[1])) == 1 {
+			name = strings.TrimPrefix(k, APIKeyPrefix)
+			scope = parts[0]
+		}
+		return nil
+	})
+	return name, scope
+}
+
+// HashPassword derives a salted hash of a basic auth password
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// checkPassword compares a password with a hash made by HashPassword
+func checkPassword(password string, stored string) bool {
+	cacheKey := hashAPIKey(stored + "\x00" + password)
+	verifiedMu.Lock()
+	ok := verifiedPasswords[cacheKey]
+	verifiedMu.Unlock()
+	if ok {
+		return true
+	}
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err1 := strconv.Atoi(parts[1])
+	salt, err2 := base64.RawStdEncoding.DecodeString(parts[2])
+	want, err3 := base64.RawStdEncoding.DecodeString(parts[3])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return false
+	}
+	hash, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil || subtle.ConstantTimeCompare(hash, want) != 1 {
+		return false
+	}
+
+	verifiedMu.Lock()
+	verifiedPasswords[cacheKey] = true
+	verifiedMu.Unlock()
+	return true
+}
+
+// requiredScope tells which scope a request needs, empty for probes that are always open
+func requiredScope(r *http.Request) string {
+	return routeScope(r.Method, r.URL.Path)
+}
+
+// routeScope tells which scope a method and path need
+func routeScope(method string, path string) string {
+	switch {
+	case path == "/healthz" || path == "/readyz":
+		return ""
+	case strings.HasPrefix(path, "/admin/") || (method != "GET" && method != "HEAD"):
+		return ScopeAdmin
+	}
+	return ScopeRead
+}
+
+// requestScope finds the scope and name of the credentials a request carries. Without any API key or basic auth
+// user configured the API is open, as it always was, and every request is admin.
+func (s *Server) requestScope(r *http.Request) (string, string, error) {
+	var scope, name string
+	err := s.store.View(func(tx storage.Tx) error {
+		user := storage.Setting(tx, "basicAuthUser")
+		if len(APIKeyScopes(tx)) == 0 && user == "" {
+			scope = ScopeAdmin
+			return nil
+		}
+
+		key := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+		// OBS browser sources cannot send headers, so the overlay takes the key from its URL
+		if key == "" && strings.HasPrefix(r.URL.Path, "/overlay") {
+			key = r.URL.Query().Get("key")
+		}
+		if key != "" {
+			name, scope = apiKeyScope(tx, key)
+			return nil
+		}
+
+		if u, p, ok := r.BasicAuth(); ok && user != "" && subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1 {
+			password, _ := tx.Config("basicAuthPassword")
+			if checkPassword(p, password) {
+				name = u
+				scope = storage.Setting(tx, "basicAuthScope")
+			}
+		}
+		return nil
+	})
+	return scope, name, err
+}
+
+// credentialsKey the request context key of the name of the API key or basic auth user of a request
+type credentialsKey struct{}
+
+// requestAuthor names who sent a request for notes and labels: the API key or basic auth user, or what the
+// open API was told
+func requestAuthor(r *http.Request, told string) string {
+	if name, _ := r.Context().Value(credentialsKey{}).(string); name != "" {
+		return name
+	}
+	if told = strings.TrimSpace(told); told != "" {
+		return told
+	}
+	return "anonymous"
+}
+
+// withAuth rejects requests without credentials of the scope they need
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		need := requiredScope(r)
+		if need == "" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope, name, err := s.requestScope(r)
+		if err != nil {
+			w.WriteHeader(500)
+			return
+		}
+		if scope == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="TUT"`)
+			w.WriteHeader(401)
+			return
+		}
+		if need == ScopeAdmin && scope != ScopeAdmin {
+			w.WriteHeader(403)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), credentialsKey{}, name)))
+	})
+}
+
+// withCORS lets pages served from corsOrigins call the API with methods. "*" allows any origin, but without
+// the browser sending basic auth credentials along, API keys still work.
+func withCORS(origins []string, methods string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			for _, allowed := range origins {
+				if allowed == origin {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+					w.Header().Add("Vary", "Origin")
+					break
+				}
+				if allowed == "*" {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+					break
+				}
+			}
+		}
+		if r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key, Content-Type")
+			w.WriteHeader(204)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseOrigins splits the corsOrigins setting
+func parseOrigins(s string) []string {
+	var origins []string
+	for _, o := range strings.Split(s, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
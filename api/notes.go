@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+	"github.com/gorilla/mux"
+)
+
+// noteQuery the parameters of writing a note, also accepted as a form body
+var noteQuery = []queryParam{
+	{"text", "text of the note", true},
+	{"author", "who wrote it, only used without API keys or basic auth, which name the author themselves", false},
+}
+
+// GetUserNotes find the notes and labels of a user by ID or login
+func (s *Server) GetUserNotes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var notes tracker.UserNotes
+	err := s.store.View(func(tx storage.Tx) error {
+		notes = tracker.GetNotes(tx, tracker.ResolveUserID(tx, params["id"]))
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(notes)
+}
+
+// SearchNotes find users with a label, ?label=, or whose notes or labels contain ?q=
+func (s *Server) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	var found []tracker.UserNotes
+	err := s.store.View(func(tx storage.Tx) error {
+		found = tracker.SearchNotes(tx, r.URL.Query().Get("label"), r.URL.Query().Get("q"))
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(found)
+}
+
+// PostUserNote add a note to a user by ID or login, Twitch is asked for logins TUT doesn't know
+func (s *Server) PostUserNote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := s.tracker.ResolveUser(params["id"])
+	if err != nil {
+		w.WriteHeader(404)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var note tracker.Note
+	err = s.store.Update(func(tx storage.Tx) error {
+		var err error
+		note, err = tracker.AddNote(tx, id, r.FormValue("text"), requestAuthor(r, r.FormValue("author")))
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(201)
+	json.NewEncoder(w).Encode(note)
+}
+
+// PutUserNote change the text of a note of a user by ID or login
+func (s *Server) PutUserNote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	noteID, err := strconv.Atoi(params["note"])
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte("PutUserNote: invalid note ID " + params["note"]))
+		return
+	}
+
+	var note tracker.Note
+	var found bool
+	err = s.store.Update(func(tx storage.Tx) error {
+		var err error
+		id := tracker.ResolveUserID(tx, params["id"])
+		note, found, err = tracker.EditNote(tx, id, noteID, r.FormValue("text"), requestAuthor(r, r.FormValue("author")))
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(note)
+}
+
+// DeleteUserNote delete a note of a user by ID or login
+func (s *Server) DeleteUserNote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	noteID, err := strconv.Atoi(params["note"])
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte("DeleteUserNote: invalid note ID " + params["note"]))
+		return
+	}
+
+	var notes tracker.UserNotes
+	var found bool
+	err = s.store.Update(func(tx storage.Tx) error {
+		var err error
+		id := tracker.ResolveUserID(tx, params["id"])
+		found, err = tracker.DeleteNote(tx, id, noteID)
+		notes = tracker.GetNotes(tx, id)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !found {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(notes)
+}
+
+// PutUserLabel label a user by ID or login, Twitch is asked for logins TUT doesn't know
+func (s *Server) PutUserLabel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := s.tracker.ResolveUser(params["id"])
+	if err != nil {
+		w.WriteHeader(404)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var notes tracker.UserNotes
+	err = s.store.Update(func(tx storage.Tx) error {
+		err := tracker.AddLabel(tx, id, params["label"], requestAuthor(r, r.FormValue("author")))
+		notes = tracker.GetNotes(tx, id)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(notes)
+}
+
+// DeleteUserLabel remove a label of a user by ID or login
+func (s *Server) DeleteUserLabel(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var notes tracker.UserNotes
+	var removed bool
+	err := s.store.Update(func(tx storage.Tx) error {
+		var err error
+		id := tracker.ResolveUserID(tx, params["id"])
+		removed, err = tracker.RemoveLabel(tx, id, params["label"])
+		notes = tracker.GetNotes(tx, id)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !removed {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(notes)
+}
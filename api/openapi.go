@@ -0,0 +1,240 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// openAPINotes describes JSON fields whose name doesn't tell what they hold, by "<schema>.<field>"
+var openAPINotes = map[string]string{
+	"Unfollowed.unfollowedAt":  "When the tracked channel unfollowed the user. Named unfollowedAt, not unfollowingAt, for compatibility.",
+	"Unfollower.unfollowedAt":  "When the user unfollowed the tracked channel.",
+	"User.unfollowedAt":        "When the user last unfollowed, empty if they never did.",
+	"TimelineEntry.source":     "events when TUT saw it happen, otherwise the relationship list it is derived from.",
+	"StreamEvent.relation":     "during when the event happened while live, otherwise before or after the nearest stream.",
+	"JobStatus.lastResult":     "ok or failed, empty before the first run.",
+	"SyncStatus.lastComplete":  "When every page of the list was last read and diffed.",
+	"Stats.bucket":             "day, week or month.",
+	"TwitchState.tokenValid":   "false once Twitch rejected ClientID or OAuth token.",
+	"Status.enrichmentBacklog": "Followers and following whose profile is not fetched yet.",
+	"Milestone.followers":      "The follower count of the milestone.",
+	"Milestone.reachedAt":      "When the follower count last reached it, empty if it did before TUT watched it.",
+	"Milestone.progress":       "Percent of the milestone the follower count reached, at most 100.",
+	"Goals.goal":               "The followerGoal setting, none when it is 0.",
+	"Goals.next":               "The lowest milestone not reached, none when all are.",
+	"Roles.subTier":            "1, 2 or 3, empty for users who don't subscribe.",
+	"Roles.banExpiresAt":       "When a timeout ends, empty for permanent bans.",
+	"RoleSync.lastSync":        "When the kind of role was last read, empty if Twitch never answered.",
+	"RoleSync.error":           "Why the last read failed, usually a scope the token lacks.",
+	"Unfollower.reason":        "banned, account_unavailable or account_closed when the unfollow wasn't the user's choice.",
+	"AccountState.status":      "unavailable while Twitch doesn't return the account, closed once confirmed, restored when it came back.",
+	"Stats.unfollowsClosed":    "Unfollowers whose accounts were deleted or suspended, confirmed or not.",
+	"GrowthStat.removed":       "Unfollows of banned users and closed accounts, included in unfollows.",
+	"OverlayEvent.followers":   "Followers after the event, 0 in the recent lists of /overlay/state.",
+	"OverlayState.recent":      "How many recent followers and unfollowers the overlay lists.",
+}
+
+// openAPIErrors describes the error statuses of routes
+var openAPIErrors = map[int]string{
+	400: "Invalid parameter, the body tells which",
+	401: "Missing or unknown credentials",
+	403: "The credentials lack the admin scope",
+	404: "Not found",
+	500: "The store failed",
+	503: "Not healthy or not ready, the body lists why",
+}
+
+// OpenAPISpec builds an OpenAPI 3 document of the routes, response schemas are reflected from the Go types
+func OpenAPISpec() map[string]interface{} {
+	// Handlers are only named here, not called, so no server is needed
+	var s *Server
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+	for _, rt := range s.routes() {
+		op := map[string]interface{}{
+			"operationId": handlerName(rt.handler),
+			"summary":     rt.summary,
+		}
+
+		var params []interface{}
+		for _, part := range strings.Split(rt.path, "/") {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				params = append(params, map[string]interface{}{
+					"name":     strings.Trim(part, "{}"),
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]string{"type": "string"},
+				})
+			}
+		}
+		for _, q := range rt.query {
+			params = append(params, map[string]interface{}{
+				"name":        q.name,
+				"in":          "query",
+				"description": q.description,
+				"required":    q.required,
+				"schema":      map[string]string{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		contentType := rt.contentType
+		var schema interface{} = map[string]string{"type": "string"}
+		if rt.response != nil {
+			contentType = "application/json"
+			schema = openAPISchema(reflect.TypeOf(rt.response), schemas)
+		}
+		if contentType == "application/octet-stream" {
+			schema = map[string]string{"type": "string", "format": "binary"}
+		}
+		responses := map[string]interface{}{
+			strconv.Itoa(rt.status): map[string]interface{}{
+				"description": rt.summary,
+				"content":     map[string]interface{}{contentType: map[string]interface{}{"schema": schema}},
+			},
+		}
+		statuses := rt.errors
+		switch routeScope(rt.method, rt.path) {
+		case "":
+			op["security"] = []interface{}{}
+		case ScopeAdmin:
+			op["description"] = "Needs the admin scope."
+			statuses = append(statuses, 401, 403)
+		default:
+			op["description"] = "Needs the read scope."
+			statuses = append(statuses, 401)
+		}
+		for _, status := range statuses {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": openAPIErrors[status],
+				"content":     map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]string{"type": "string"}}},
+			}
+		}
+		op["responses"] = responses
+
+		if paths[rt.path] == nil {
+			paths[rt.path] = make(map[string]interface{})
+		}
+		paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":       "Twitch Unfollow Tracker API",
+			"version":     tracker.Version,
+			"description": "Followers, unfollowers and stats TUT tracked of a Twitch channel. Without API keys or basic auth user configured, every endpoint is open.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]string{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": map[string]string{"type": "http", "scheme": "bearer"},
+				"basic":  map[string]string{"type": "http", "scheme": "basic"},
+			},
+		},
+		"security": []interface{}{
+			map[string][]string{"apiKey": {}},
+			map[string][]string{"bearer": {}},
+			map[string][]string{"basic": {}},
+		},
+	}
+}
+
+// openAPISchema describes a Go type, structs are added to schemas by their name and referenced
+func openAPISchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": openAPISchema(t.Elem(), schemas)}
+	case reflect.Ptr:
+		return openAPISchema(t.Elem(), schemas)
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			// Claim the name before the fields, a type may refer to itself
+			schemas[t.Name()] = nil
+			properties := make(map[string]interface{})
+			var required []string
+			openAPIFields(t, t.Name(), properties, &required, schemas)
+			sort.Strings(required)
+			object := map[string]interface{}{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				object["required"] = required
+			}
+			schemas[t.Name()] = object
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// openAPIFields adds the JSON fields of a struct to properties, embedded structs add theirs as encoding/json does
+func openAPIFields(t reflect.Type, name string, properties map[string]interface{}, required *[]string, schemas map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if f.Anonymous && tag[0] == "" && f.Type.Kind() == reflect.Struct {
+			openAPIFields(f.Type, name, properties, required, schemas)
+			continue
+		}
+		if f.PkgPath != "" || tag[0] == "-" {
+			continue
+		}
+		field := tag[0]
+		if field == "" {
+			field = f.Name
+		}
+
+		schema := openAPISchema(f.Type, schemas)
+		if note, ok := openAPINotes[name+"."+field]; ok {
+			if _, ref := schema["$ref"]; ref {
+				// Siblings of $ref are ignored in OpenAPI 3.0
+				schema = map[string]interface{}{"allOf": []interface{}{schema}}
+			}
+			schema["description"] = note
+		}
+		properties[field] = schema
+
+		omitempty := false
+		for _, option := range tag[1:] {
+			omitempty = omitempty || option == "omitempty"
+		}
+		if !omitempty {
+			*required = append(*required, field)
+		}
+	}
+}
+
+// handlerName the method name of a handler, used as operationId
+func handlerName(h http.HandlerFunc) string {
+	name := strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name(), "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// GetOpenAPI serve the OpenAPI document of the API
+func (s *Server) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(OpenAPISpec())
+}
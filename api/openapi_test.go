@@ -0,0 +1,26 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// TestOpenAPIUpToDate fails when a route changed without running go generate ./client
+func TestOpenAPIUpToDate(t *testing.T) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(OpenAPISpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := ioutil.ReadFile("../openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), committed) {
+		t.Error("openapi.json doesn't match the routes, run go generate ./client")
+	}
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// OverlayEvent a follow, refollow, unfollow or milestone as the overlay shows it, Followers counts the followers after it
+type OverlayEvent struct {
+	ID        uint64            `json:"id"`
+	Type      string            `json:"type"`
+	User      User              `json:"user"`
+	At        string            `json:"at"`
+	Followers int               `json:"followers"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// OverlayState what the overlay shows when it loads, recent events come newest first
+type OverlayState struct {
+	Followers         int            `json:"followers"`
+	Recent            int            `json:"recent"`
+	Goal              int            `json:"goal"`
+	GoalLabel         string         `json:"goalLabel"`
+	AlertSeconds      int            `json:"alertSeconds"`
+	FollowText        string         `json:"followText"`
+	RefollowText      string         `json:"refollowText"`
+	MilestoneText     string         `json:"milestoneText"`
+	RecentFollowers   []OverlayEvent `json:"recentFollowers"`
+	RecentUnfollowers []OverlayEvent `json:"recentUnfollowers"`
+}
+
+// overlayWidgets every part of the overlay, overlayWidgets and ?widgets= pick some
+var overlayWidgets = []string{"alerts", "recent", "goal", "unfollowers"}
+
+// overlayThemes built-in looks of the overlay, overlayCSS adds to them
+var overlayThemes = map[string]string{
+	"dark":        `:root { --bg: rgba(20, 20, 28, 0.85); --fg: #fff; --muted: #b9b9c6; --accent: #9146ff; --shadow: none; }`,
+	"light":       `:root { --bg: rgba(255, 255, 255, 0.9); --fg: #18181b; --muted: #53535f; --accent: #9146ff; --shadow: none; }`,
+	"transparent": `:root { --bg: transparent; --fg: #fff; --muted: #eee; --accent: #9146ff; --shadow: 0 0 4px #000, 0 0 2px #000; }`,
+}
+
+// overlayHub hands live events to every connected overlay
+type overlayHub struct {
+	mu      sync.Mutex
+	clients map[chan OverlayEvent]bool
+	queue   chan tracker.Event
+}
+
+// subscribe adds an overlay, it misses events while its channel is full
+func (h *overlayHub) subscribe() chan OverlayEvent {
+	ch := make(chan OverlayEvent, 16)
+	h.mu.Lock()
+	h.clients[ch] = true
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *overlayHub) unsubscribe(ch chan OverlayEvent) {
+	h.mu.Lock()
+	delete(h.clients, ch)
+	h.mu.Unlock()
+}
+
+// notify queues follows, refollows, unfollows and milestones for the overlays, for use with tracker.OnEvent.
+// Banned users and closed accounts are no unfollows to show on stream.
+func (h *overlayHub) notify(e tracker.Event) {
+	switch e.Type {
+	case storage.EventFollow, storage.EventRefollow, storage.EventMilestone:
+	case storage.EventUnfollow:
+		if e.Details["reason"] != "" {
+			return
+		}
+	default:
+		return
+	}
+	select {
+	case h.queue <- e:
+	default:
+		fmt.Printf("[SYS] Too many overlay events queued, dropped %s of %s\n", e.Type, e.UserID)
+	}
+}
+
+// broadcastOverlay looks up the profile of queued events, from Twitch for brand new followers, and sends them to every overlay
+func (s *Server) broadcastOverlay() {
+	for e := range s.overlay.queue {
+		// The profiles job fetches new followers later, alerts need their name and image now
+		if e.Type == storage.EventFollow || e.Type == storage.EventRefollow {
+			_, err := s.tracker.FetchUser(e.UserID)
+			if err != nil {
+				fmt.Printf("[SYS] Overlay cannot get the profile of %s: %v\n", e.UserID, err)
+			}
+		}
+		var oe OverlayEvent
+		s.store.View(func(tx storage.Tx) error {
+			oe = overlayEvent(tx, e)
+			oe.Followers = tx.CountRelations(storage.ListFollowers)
+			return nil
+		})
+
+		s.overlay.mu.Lock()
+		for ch := range s.overlay.clients {
+			select {
+			case ch <- oe:
+			default:
+			}
+		}
+		s.overlay.mu.Unlock()
+	}
+}
+
+// overlayEvent adds the stored profile to e, falling back to what the event knows
+func overlayEvent(tx storage.Tx, e tracker.Event) OverlayEvent {
+	u := User{ID: e.UserID, Login: e.Login, Displayname: e.Displayname, FollowedAt: e.FollowedAt}
+	if profile, ok := tracker.GetUserProfile(tx, e.UserID); ok && profile["login"] != "" {
+		u.Login = profile["login"]
+		u.Displayname = profile["display_name"]
+		u.ProfileImageURL = profile["profile_image_url"]
+	}
+	if u.Displayname == "" {
+		u.Displayname = u.Login
+	}
+	if e.Type == storage.EventUnfollow {
+		u.UnfollowedAt = e.At
+	}
+	return OverlayEvent{ID: e.ID, Type: e.Type, User: u, At: e.At, Details: e.Details}
+}
+
+// GetOverlayState find what the overlay shows when it loads
+func (s *Server) GetOverlayState(w http.ResponseWriter, r *http.Request) {
+	state := OverlayState{RecentFollowers: []OverlayEvent{}, RecentUnfollowers: []OverlayEvent{}}
+	err := s.store.View(func(tx storage.Tx) error {
+		state.Followers = tx.CountRelations(storage.ListFollowers)
+		state.Goal = storage.IntSetting(tx, "overlayGoal")
+		if state.Goal == 0 {
+			state.Goal = storage.IntSetting(tx, "followerGoal")
+		}
+		state.GoalLabel = storage.Setting(tx, "overlayGoalLabel")
+		state.AlertSeconds = storage.IntSetting(tx, "overlayAlertSeconds")
+		state.FollowText = storage.Setting(tx, "overlayFollowText")
+		state.RefollowText = storage.Setting(tx, "overlayRefollowText")
+		state.MilestoneText = storage.Setting(tx, "overlayMilestoneText")
+		state.Recent = storage.IntSetting(tx, "overlayRecent")
+
+		var follows, unfollows []tracker.Event
+		tags := tracker.AllTags(tx)
+		err := tx.ForEachEvent(func(e tracker.Event) error {
+			if tracker.HasTag(tags[e.UserID], tracker.TagIgnore) {
+				return nil
+			}
+			switch e.Type {
+			case storage.EventFollow, storage.EventRefollow:
+				follows = append(follows, e)
+			case storage.EventUnfollow:
+				if e.Details["reason"] == "" {
+					unfollows = append(unfollows, e)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i := len(follows) - 1; i >= 0 && len(state.RecentFollowers) < state.Recent; i-- {
+			state.RecentFollowers = append(state.RecentFollowers, overlayEvent(tx, follows[i]))
+		}
+		for i := len(unfollows) - 1; i >= 0 && len(state.RecentUnfollowers) < state.Recent; i-- {
+			state.RecentUnfollowers = append(state.RecentUnfollowers, overlayEvent(tx, unfollows[i]))
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(state)
+}
+
+// GetOverlayEvents streams follows, refollows, unfollows and milestones as server-sent events named after their type
+func (s *Server) GetOverlayEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(500)
+		w.Write([]byte("streaming is not supported"))
+		return
+	}
+	ch := s.overlay.subscribe()
+	defer s.overlay.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(200)
+	fmt.Fprintf(w, ": connected\n\n")
+	flusher.Flush()
+
+	// Comments keep proxies and OBS from closing an idle stream
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			flusher.Flush()
+		case <-ping.C:
+			fmt.Fprintf(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// GetOverlay serves the overlay page for an OBS browser source, ?theme= and ?widgets= override the settings
+func (s *Server) GetOverlay(w http.ResponseWriter, r *http.Request) {
+	var theme, widgets, cssFile string
+	s.store.View(func(tx storage.Tx) error {
+		theme = storage.Setting(tx, "overlayTheme")
+		widgets = storage.Setting(tx, "overlayWidgets")
+		cssFile = storage.Setting(tx, "overlayCSS")
+		return nil
+	})
+	if q := r.URL.Query().Get("theme"); q != "" {
+		theme = q
+	}
+	if q := r.URL.Query().Get("widgets"); q != "" {
+		widgets = q
+	}
+	themeCSS, ok := overlayThemes[theme]
+	if !ok {
+		w.WriteHeader(400)
+		w.Write([]byte(fmt.Sprintf("unknown theme %q, use dark, light or transparent", theme)))
+		return
+	}
+
+	show := make(map[string]bool)
+	for _, widget := range strings.Split(widgets, ",") {
+		widget = strings.TrimSpace(widget)
+		if widget == "" {
+			continue
+		}
+		if !contains(overlayWidgets, widget) {
+			w.WriteHeader(400)
+			w.Write([]byte(fmt.Sprintf("unknown widget %q, use %s", widget, strings.Join(overlayWidgets, ", "))))
+			return
+		}
+		show[widget] = true
+	}
+
+	var customCSS []byte
+	if cssFile != "" {
+		var err error
+		customCSS, err = ioutil.ReadFile(cssFile)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	overlayPage.Execute(w, map[string]interface{}{
+		"Theme":  template.CSS(themeCSS),
+		"Custom": template.CSS(customCSS),
+		"Show":   show,
+	})
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+var overlayPage = template.Must(template.New("overlay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>TUT Overlay</title>
+<style>
+{{.Theme}}
+body { margin: 0; padding: 16px; background: transparent; color: var(--fg); font: 600 20px/1.3 "Segoe UI", Roboto, Helvetica, Arial, sans-serif; text-shadow: var(--shadow); overflow: hidden; }
+.box { background: var(--bg); border-radius: 10px; padding: 12px 16px; margin-bottom: 12px; max-width: 420px; }
+.title { color: var(--muted); font-size: 14px; text-transform: uppercase; letter-spacing: 0.05em; margin-bottom: 6px; }
+.user { display: flex; align-items: center; gap: 10px; margin: 4px 0; }
+.user img { width: 32px; height: 32px; border-radius: 50%; }
+#alert { display: flex; align-items: center; gap: 16px; opacity: 0; transform: translateY(-20px); transition: opacity 0.4s, transform 0.4s; }
+#alert.show { opacity: 1; transform: none; }
+#alert img { width: 72px; height: 72px; border-radius: 50%; border: 3px solid var(--accent); }
+#alert-text { color: var(--muted); font-size: 18px; }
+#alert-name { font-size: 30px; color: var(--accent); }
+.bar { height: 18px; border-radius: 9px; background: rgba(128, 128, 128, 0.35); overflow: hidden; margin-top: 6px; }
+.bar div { height: 100%; width: 0; background: var(--accent); transition: width 0.6s; }
+.hidden { display: none; }
+{{.Custom}}
+</style>
+</head>
+<body>
+{{if .Show.alerts}}<div id="alert" class="box"><img id="alert-image" alt=""><div><div id="alert-text"></div><div id="alert-name"></div></div></div>{{end}}
+{{if .Show.goal}}<div id="goal" class="box hidden"><div class="title"><span id="goal-label"></span> <span id="goal-count"></span></div><div class="bar"><div id="goal-bar"></div></div></div>{{end}}
+{{if .Show.recent}}<div id="recent" class="box"><div class="title">Recent followers</div><div id="recent-list"></div></div>{{end}}
+{{if .Show.unfollowers}}<div id="unfollowers" class="box"><div class="title">Recent unfollowers</div><div id="unfollowers-list"></div></div>{{end}}
+<script>
+const params = new URLSearchParams(location.search);
+const key = params.get("key") ? "?key=" + encodeURIComponent(params.get("key")) : "";
+let state = null;
+const alerts = [];
+let alerting = false;
+
+function userRow(e) {
+	const row = document.createElement("div");
+	row.className = "user";
+	if (e.user.profileImageURL) {
+		const img = document.createElement("img");
+		img.src = e.user.profileImageURL;
+		row.appendChild(img);
+	}
+	const name = document.createElement("span");
+	name.textContent = e.user.displayname || e.user.login || e.user.id;
+	row.appendChild(name);
+	return row;
+}
+
+function renderList(id, events) {
+	const list = document.getElementById(id);
+	if (!list) return;
+	list.replaceChildren(...events.map(userRow));
+}
+
+function renderGoal() {
+	const goal = document.getElementById("goal");
+	if (!goal) return;
+	goal.classList.toggle("hidden", state.goal <= 0);
+	document.getElementById("goal-label").textContent = state.goalLabel;
+	document.getElementById("goal-count").textContent = state.followers + " / " + state.goal;
+	document.getElementById("goal-bar").style.width = Math.min(100, 100 * state.followers / Math.max(state.goal, 1)) + "%";
+}
+
+function render() {
+	renderList("recent-list", state.recentFollowers);
+	renderList("unfollowers-list", state.recentUnfollowers);
+	renderGoal();
+}
+
+function nextAlert() {
+	const box = document.getElementById("alert");
+	if (!box || alerting || alerts.length == 0) return;
+	const e = alerts.shift();
+	alerting = true;
+	const milestone = e.type == "milestone";
+	document.getElementById("alert-text").textContent = milestone ? state.milestoneText : e.type == "refollow" ? state.refollowText : state.followText;
+	document.getElementById("alert-name").textContent = milestone ? Number(e.details.milestone).toLocaleString() + " followers" : e.user.displayname || e.user.login || e.user.id;
+	const img = document.getElementById("alert-image");
+	img.src = e.user.profileImageURL || "";
+	img.classList.toggle("hidden", !e.user.profileImageURL);
+	box.classList.add("show");
+	setTimeout(() => {
+		box.classList.remove("show");
+		setTimeout(() => { alerting = false; nextAlert(); }, 500);
+	}, state.alertSeconds * 1000);
+}
+
+function onEvent(msg) {
+	const e = JSON.parse(msg.data);
+	if (!state) return;
+	state.followers = e.followers;
+	if (e.type == "milestone") {
+		if (e.details.direction == "reached") {
+			alerts.push(e);
+			nextAlert();
+		}
+	} else if (e.type == "unfollow") {
+		state.recentUnfollowers = [e, ...state.recentUnfollowers].slice(0, state.recent);
+	} else {
+		state.recentFollowers = [e, ...state.recentFollowers].slice(0, state.recent);
+		alerts.push(e);
+		nextAlert();
+	}
+	render();
+}
+
+async function loadState() {
+	const resp = await fetch("overlay/state" + key);
+	if (resp.ok) {
+		state = await resp.json();
+		render();
+	}
+}
+
+const events = new EventSource("overlay/events" + key);
+events.onopen = loadState;
+for (const type of ["follow", "refollow", "unfollow", "milestone"]) {
+	events.addEventListener(type, onEvent);
+}
+</script>
+</body>
+</html>
+`))
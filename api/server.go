@@ -0,0 +1,920 @@
+// Package api serves what a tracker recorded over HTTP, with API keys or basic auth, CORS and TLS
+// configured by settings in the store.
+//
+//	log.Fatal(api.New(t).ListenAndServe("25001"))
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+	"github.com/gorilla/mux"
+)
+
+// defaultSearchLimit caps the users /users/search returns
+const defaultSearchLimit = 50
+
+// Server the HTTP API of a tracker
+type Server struct {
+	tracker *tracker.Tracker
+	store   storage.Store
+	overlay *overlayHub
+}
+
+// New creates the API of t and starts passing its events to overlays
+func New(t *tracker.Tracker) *Server {
+	s := &Server{tracker: t, store: t.Store(), overlay: &overlayHub{clients: make(map[chan OverlayEvent]bool), queue: make(chan tracker.Event, 100)}}
+	t.OnEvent(s.overlay.notify)
+	go s.broadcastOverlay()
+	return s
+}
+
+// route one endpoint of the API. The router, the root page and /openapi.json are all built from routes.
+type route struct {
+	method      string
+	path        string
+	handler     http.HandlerFunc
+	summary     string
+	query       []queryParam
+	status      int
+	errors      []int
+	response    interface{} // value of the JSON response type, nil if the response isn't JSON
+	contentType string
+}
+
+// queryParam a query parameter of a route
+type queryParam struct {
+	name        string
+	description string
+	required    bool
+}
+
+// routes lists every endpoint of the API
+func (s *Server) routes() []route {
+	statsQuery := []queryParam{
+		{"bucket", "day, week or month, defaults to the statsBucket setting", false},
+		{"tz", "IANA time zone of the buckets, defaults to the statsTimezone setting", false},
+	}
+	return []route{
+		{"GET", "/", s.GetRoot, "List the endpoints", nil, 200, nil, nil, "text/html"},
+		{"GET", "/followers", s.GetFollowers, "Current followers", tagQuery, 200, []int{400, 500}, []User{}, ""},
+		{"GET", "/refollowers", s.GetRefollowers, "Followers that unfollowed before", tagQuery, 200, []int{400, 500}, []User{}, ""},
+		{"GET", "/followersID", s.GetFollowersID, "IDs of current followers", tagQuery, 200, []int{400, 500}, []int{}, ""},
+		{"GET", "/unfollowers", s.GetUnfollowers, "Users that unfollowed the channel", append([]queryParam{{"reason", "genuine, banned, account_unavailable or account_closed", false}}, tagQuery...), 200, []int{400, 500}, []Unfollower{}, ""},
+		{"GET", "/following", s.GetFollowing, "Users the channel follows", tagQuery, 200, []int{400, 500}, []User{}, ""},
+		{"GET", "/refollowing", s.GetRefollowing, "Users the channel follows again after unfollowing them", tagQuery, 200, []int{400, 500}, []User{}, ""},
+		{"GET", "/followingID", s.GetFollowingID, "IDs of users the channel follows", tagQuery, 200, []int{400, 500}, []int{}, ""},
+		{"GET", "/unfollowing", s.GetUnfollowing, "Users the channel unfollowed", tagQuery, 200, []int{400, 500}, []Unfollowed{}, ""},
+		{"GET", "/user/by-login/{login}", s.GetUserByLogin, "Profile and relationship of a user by login", nil, 200, []int{404, 500}, tracker.UserDetail{}, ""},
+		{"GET", "/user/{id}", s.GetUser, "Profile and relationship of a user", nil, 200, []int{404, 500}, tracker.UserDetail{}, ""},
+		{"GET", "/user/{id}/timeline", s.GetUserTimeline, "Everything TUT knows about a user, by ID or login", nil, 200, []int{404, 500}, tracker.Timeline{}, ""},
+		{"GET", "/tags", s.GetTags, "Tagged users", []queryParam{{"tag", "only users with this tag", false}}, 200, []int{400, 500}, []tracker.UserDetail{}, ""},
+		{"PUT", "/user/{id}/tags/{tag}", s.PutUserTag, "Tag a user by ID or login, ignore, watch, vip or any other tag", nil, 200, []int{400, 404, 500}, []string{}, ""},
+		{"DELETE", "/user/{id}/tags/{tag}", s.DeleteUserTag, "Remove a tag of a user by ID or login", nil, 200, []int{404, 500}, []string{}, ""},
+		{"GET", "/user/{id}/notes", s.GetUserNotes, "Notes and labels of a user by ID or login", nil, 200, []int{500}, tracker.UserNotes{}, ""},
+		{"POST", "/user/{id}/notes", s.PostUserNote, "Add a note to a user by ID or login", noteQuery, 201, []int{400, 404, 500}, tracker.Note{}, ""},
+		{"PUT", "/user/{id}/notes/{note}", s.PutUserNote, "Change the text of a note", noteQuery, 200, []int{400, 404, 500}, tracker.Note{}, ""},
+		{"DELETE", "/user/{id}/notes/{note}", s.DeleteUserNote, "Delete a note", nil, 200, []int{400, 404, 500}, tracker.UserNotes{}, ""},
+		{"PUT", "/user/{id}/labels/{label}", s.PutUserLabel, "Label a user by ID or login, e.g. sub since 2019", []queryParam{noteQuery[1]}, 200, []int{400, 404, 500}, tracker.UserNotes{}, ""},
+		{"DELETE", "/user/{id}/labels/{label}", s.DeleteUserLabel, "Remove a label of a user", nil, 200, []int{404, 500}, tracker.UserNotes{}, ""},
+		{"GET", "/notes", s.SearchNotes, "Users with notes or labels", []queryParam{{"label", "only users with this label, ignoring case", false}, {"q", "only users whose notes or labels contain q, ignoring case", false}}, 200, []int{500}, []tracker.UserNotes{}, ""},
+		{"GET", "/users/search", s.SearchUsers, "Users whose login starts with q", append([]queryParam{{"q", "login prefix", true}}, tagQuery...), 200, []int{400, 500}, []tracker.UserDetail{}, ""},
+		{"GET", "/suspicious", s.GetSuspicious, "Followers and former followers that look like bots", append([]queryParam{{"min", "minimum score, defaults to the suspiciousScore setting", false}}, tagQuery...), 200, []int{400, 500}, []tracker.SuspiciousUser{}, ""},
+		{"GET", "/stats", s.GetStats, "Churn and growth numbers", statsQuery, 200, []int{400, 500}, tracker.Stats{}, ""},
+		{"GET", "/stats/growth", s.GetGrowthStats, "Follows, unfollows and net change per bucket", statsQuery, 200, []int{400, 500}, []tracker.GrowthStat{}, ""},
+		{"GET", "/stats/churn-hours", s.GetChurnHourStats, "Unfollows per hour of the day", statsQuery, 200, []int{400, 500}, []tracker.ChurnHour{}, ""},
+		{"GET", "/goals", s.GetGoals, "Progress towards followerGoal and the follower milestones", nil, 200, []int{500}, tracker.Goals{}, ""},
+		{"GET", "/streams", s.GetStreams, "Stream sessions with follows and unfollows during and around them", nil, 200, []int{500}, []tracker.StreamStat{}, ""},
+		{"GET", "/streams/{id}/events", s.GetStreamEvents, "Follows and unfollows of a stream session", nil, 200, []int{404, 500}, []tracker.StreamEvent{}, ""},
+		{"GET", "/admin/backup", s.GetBackup, "Download a consistent copy of the database", nil, 200, nil, nil, "application/octet-stream"},
+		{"POST", "/admin/sync", s.PostSync, "Run jobs now", []queryParam{{"job", "followers, following, profiles, roles or accounts, may repeat, both syncs by default", false}}, 202, []int{400}, []tracker.JobStatus{}, ""},
+		{"GET", "/schedule", s.GetSchedule, "Jobs with their schedule, last run and next run", nil, 200, nil, []tracker.JobStatus{}, ""},
+		{"GET", "/healthz", s.GetHealthz, "200 while TUT runs and the store can be opened", nil, 200, []int{503}, nil, "text/plain"},
+		{"GET", "/readyz", s.GetReadyz, "200 once a follower sync completed and Twitch accepts the token", nil, 200, []int{503}, nil, "text/plain"},
+		{"GET", "/status", s.GetStatus, "Version, tracked channel, syncs, enrichment backlog and rate limit", nil, 200, []int{500}, tracker.Status{}, ""},
+		{"GET", "/overlay", s.GetOverlay, "Overlay page for an OBS browser source", []queryParam{{"theme", "dark, light or transparent, defaults to the overlayTheme setting", false}, {"widgets", "comma separated alerts, recent, goal and unfollowers, defaults to the overlayWidgets setting", false}, {"key", "API key, as OBS cannot send headers, also used for the requests of the page", false}}, 200, []int{400, 500}, nil, "text/html"},
+		{"GET", "/overlay/state", s.GetOverlayState, "Follower count, goal and recent events the overlay shows when it loads", nil, 200, []int{500}, OverlayState{}, ""},
+		{"GET", "/overlay/events", s.GetOverlayEvents, "Follows, refollows, unfollows and milestones as server-sent events as they happen", nil, 200, []int{500}, nil, "text/event-stream"},
+		{"GET", "/openapi.json", s.GetOpenAPI, "This OpenAPI document", nil, 200, nil, map[string]interface{}{}, ""},
+	}
+}
+
+// Handler routes requests to the endpoints, behind auth and CORS
+func (s *Server) Handler() http.Handler {
+	router := mux.NewRouter()
+	var methods []string
+	for _, rt := range s.routes() {
+		router.HandleFunc(rt.path, rt.handler).Methods(rt.method)
+		if !contains(methods, rt.method) {
+			methods = append(methods, rt.method)
+		}
+	}
+
+	var cors string
+	s.store.View(func(tx storage.Tx) error {
+		cors = storage.Setting(tx, "corsOrigins")
+		return nil
+	})
+	return withCORS(parseOrigins(cors), strings.Join(append(methods, "OPTIONS"), ", "), s.withAuth(router))
+}
+
+// ListenAndServe serves the API on port of bindAddress, over TLS when tlsCert and tlsKey are set
+func (s *Server) ListenAndServe(port string) error {
+	var bind, cert, key string
+	var open bool
+	s.store.View(func(tx storage.Tx) error {
+		open = len(APIKeyScopes(tx)) == 0 && storage.Setting(tx, "basicAuthUser") == ""
+		bind = storage.Setting(tx, "bindAddress")
+		cert = storage.Setting(tx, "tlsCert")
+		key = storage.Setting(tx, "tlsKey")
+		return nil
+	})
+	handler := s.Handler()
+	addr := net.JoinHostPort(bind, port)
+	host := bind
+	if host == "" {
+		host = "localhost"
+	}
+	if open {
+		fmt.Printf("[SYS] The API has no API keys or basic auth, anyone who reaches it can use it, see \"tut apikey\"\n")
+	}
+
+	if cert != "" && key != "" {
+		fmt.Printf("[SYS] Server listening at https://%s\n", net.JoinHostPort(host, port))
+		return http.ListenAndServeTLS(addr, cert, key, handler)
+	}
+	fmt.Printf("[SYS] Server listening at http://%s\n", net.JoinHostPort(host, port))
+	return http.ListenAndServe(addr, handler)
+}
+
+// GetRoot prints out root message
+func (s *Server) GetRoot(w http.ResponseWriter, r *http.Request) {
+	var items strings.Builder
+	for _, rt := range s.routes() {
+		if rt.path == "/" {
+			continue
+		}
+		var query []string
+		for _, q := range rt.query {
+			if q.required {
+				query = append(query, fmt.Sprintf("%s={%s}", q.name, q.description))
+			}
+		}
+		switch {
+		case rt.method != "GET":
+			fmt.Fprintf(&items, "\t<li>%s %s</li>\n", rt.method, html.EscapeString(rt.path))
+		case len(query) > 0:
+			fmt.Fprintf(&items, "\t<li>%s?%s</li>\n", html.EscapeString(rt.path), html.EscapeString(strings.Join(query, "&")))
+		case strings.Contains(rt.path, "{"):
+			fmt.Fprintf(&items, "\t<li>%s</li>\n", html.EscapeString(rt.path))
+		default:
+			fmt.Fprintf(&items, "\t<li><a href=\"%s\">%s</a></li>\n", rt.path, rt.path)
+		}
+	}
+
+	w.WriteHeader(200)
+	w.Write([]byte(`
+	<p>Welcome to Twitch Unfollow Tracker</p>
+	<p>Available Endpoints:</p>
+	<ul>
+` + items.String() + `	</ul>
+	`))
+}
+
+// GetReFollowers find all refollowers detailed info
+func (s *Server) GetRefollowers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	outputUsers := []User{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		return tx.ForEachRelation(storage.ListUnfollowers, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			fdata := tx.Relation(storage.ListFollowers, k)
+			if fdata == "" {
+				return nil
+			}
+			profile, _ := tracker.GetUserProfile(tx, k)
+			out := User{
+				k,
+				profile["login"],
+				profile["display_name"],
+				profile["profile_image_url"],
+				fdata,
+				v,
+				tags[k],
+				notes[k].Notes,
+				notes[k].Labels,
+				roles[k]}
+			outputUsers = append(outputUsers, out)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(outputUsers)
+}
+
+// GetReFollowing find all refollowing detailed info
+func (s *Server) GetRefollowing(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	outputUsers := []User{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		return tx.ForEachRelation(storage.ListUnfollowing, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			odata := tx.Relation(storage.ListFollowing, k)
+			if odata == "" {
+				return nil
+			}
+			profile, _ := tracker.GetUserProfile(tx, k)
+			out := User{
+				k,
+				profile["login"],
+				profile["display_name"],
+				profile["profile_image_url"],
+				odata,
+				v,
+				tags[k],
+				notes[k].Notes,
+				notes[k].Labels,
+				roles[k]}
+			outputUsers = append(outputUsers, out)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(outputUsers)
+}
+
+// GetFollowers find all followers detailed info
+func (s *Server) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	outputUsers := []User{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		return tx.ForEachRelation(storage.ListFollowers, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			profile, _ := tracker.GetUserProfile(tx, k)
+			out := User{
+				k,
+				profile["login"],
+				profile["display_name"],
+				profile["profile_image_url"],
+				v,
+				tx.Relation(storage.ListUnfollowers, k),
+				tags[k],
+				notes[k].Notes,
+				notes[k].Labels,
+				roles[k]}
+			outputUsers = append(outputUsers, out)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	sort.Slice(outputUsers, func(i, j int) bool {
+		return outputUsers[i].FollowedAt > outputUsers[j].FollowedAt
+	})
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(outputUsers)
+}
+
+// GetFollowing find all follows detailed info
+func (s *Server) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	outputUsers := []User{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		return tx.ForEachRelation(storage.ListFollowing, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			profile, _ := tracker.GetUserProfile(tx, k)
+			out := User{
+				k,
+				profile["login"],
+				profile["display_name"],
+				profile["profile_image_url"],
+				v,
+				tx.Relation(storage.ListUnfollowing, k),
+				tags[k],
+				notes[k].Notes,
+				notes[k].Labels,
+				roles[k]}
+			outputUsers = append(outputUsers, out)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	sort.Slice(outputUsers, func(i, j int) bool {
+		return outputUsers[i].FollowedAt > outputUsers[j].FollowedAt
+	})
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(outputUsers)
+}
+
+// GetFollowersID find all followers's ID
+func (s *Server) GetFollowersID(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	followIDs := []int{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		return tx.ForEachRelation(storage.ListFollowers, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			id, err := strconv.Atoi(k)
+			if err != nil {
+				return err
+			}
+			followIDs = append(followIDs, id)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(followIDs)
+}
+
+// GetFollowingID find all followers's ID
+func (s *Server) GetFollowingID(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	followingIDs := []int{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		return tx.ForEachRelation(storage.ListFollowing, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			id, err := strconv.Atoi(k)
+			if err != nil {
+				return err
+			}
+			followingIDs = append(followingIDs, id)
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(followingIDs)
+}
+
+// GetUnfollowers find all unfollowers, ?reason= for the genuine ones or the ones banned or whose accounts are gone
+func (s *Server) GetUnfollowers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	reason := r.URL.Query().Get("reason")
+	switch reason {
+	case "", "genuine", tracker.ReasonBanned, tracker.ReasonAccountUnavailable, tracker.ReasonAccountClosed:
+	default:
+		w.WriteHeader(400)
+		w.Write([]byte("GetUnfollowers: reason must be genuine, banned, account_unavailable or account_closed"))
+		return
+	}
+
+	unfollowers := []Unfollower{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		reasons := tracker.UnfollowReasons(tx)
+		return tx.ForEachRelation(storage.ListUnfollowers, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			if got := reasons[k]; reason != "" && got != reason && !(reason == "genuine" && got == "") {
+				return nil
+			}
+			profile, ok := tracker.GetUserProfile(tx, k)
+			if !ok {
+				uf := Unfollower{
+					k,
+					"Unknown",
+					"Unknown",
+					"Unknown",
+					v,
+					tags[k],
+					notes[k].Notes,
+					notes[k].Labels,
+					roles[k],
+					reasons[k]}
+				unfollowers = append(unfollowers, uf)
+			} else {
+				uf := Unfollower{
+					profile["id"],
+					profile["login"],
+					profile["display_name"],
+					profile["profile_image_url"],
+					v,
+					tags[k],
+					notes[k].Notes,
+					notes[k].Labels,
+					roles[k],
+					reasons[k]}
+				unfollowers = append(unfollowers, uf)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	sort.Slice(unfollowers, func(i, j int) bool {
+		return unfollowers[i].UnfollowedAt > unfollowers[j].UnfollowedAt
+	})
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(unfollowers)
+}
+
+// GetUnfollowing find all unfollowed
+func (s *Server) GetUnfollowing(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	unfollowing := []Unfollowed{}
+	err = s.store.View(func(tx storage.Tx) error {
+		tags := tracker.AllTags(tx)
+		notes := tracker.AllNotes(tx)
+		roles := tracker.AllRoles(tx)
+		return tx.ForEachRelation(storage.ListUnfollowing, func(k, v string) error {
+			if !filter.keep(tags[k]) {
+				return nil
+			}
+			profile, ok := tracker.GetUserProfile(tx, k)
+			if !ok {
+				uo := Unfollowed{
+					k,
+					"Unknown",
+					"Unknown",
+					"Unknown",
+					v,
+					tags[k],
+					notes[k].Notes,
+					notes[k].Labels,
+					roles[k]}
+				unfollowing = append(unfollowing, uo)
+			} else {
+				uo := Unfollowed{
+					profile["id"],
+					profile["login"],
+					profile["display_name"],
+					profile["profile_image_url"],
+					v,
+					tags[k],
+					notes[k].Notes,
+					notes[k].Labels,
+					roles[k]}
+				unfollowing = append(unfollowing, uo)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	sort.Slice(unfollowing, func(i, j int) bool {
+		return unfollowing[i].UnfollowingAt > unfollowing[j].UnfollowingAt
+	})
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(unfollowing)
+}
+
+// GetUser get specific user with the current relationship
+func (s *Server) GetUser(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var detail tracker.UserDetail
+	var found bool
+	err := s.store.View(func(tx storage.Tx) error {
+		detail, found = tracker.GetUserDetail(tx, id)
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	if found {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(detail)
+	} else {
+		w.WriteHeader(404)
+	}
+}
+
+// GetUserByLogin get specific user by login
+func (s *Server) GetUserByLogin(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	login := params["login"]
+
+	var detail tracker.UserDetail
+	var found bool
+	err := s.store.View(func(tx storage.Tx) error {
+		id := tx.LookupLogin(login)
+		if id != "" {
+			detail, found = tracker.GetUserDetail(tx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	if found {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(detail)
+	} else {
+		w.WriteHeader(404)
+	}
+}
+
+// SearchUsers find users whose login starts with query
+func (s *Server) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		w.WriteHeader(400)
+		return
+	}
+
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	users := []tracker.UserDetail{}
+	err = s.store.View(func(tx storage.Tx) error {
+		for _, id := range tx.SearchLogins(query, defaultSearchLimit) {
+			detail, _ := tracker.GetUserDetail(tx, id)
+			if filter.keep(detail.Tags) {
+				users = append(users, detail)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(users)
+}
+
+// GetUserTimeline get every follow, unfollow, refollow and profile change of a user by ID or login
+func (s *Server) GetUserTimeline(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	var timeline tracker.Timeline
+	var found bool
+	err := s.store.View(func(tx storage.Tx) error {
+		timeline, found = tracker.GetTimeline(tx, tracker.ResolveUserID(tx, params["id"]))
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	if found {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(timeline)
+	} else {
+		w.WriteHeader(404)
+	}
+}
+
+// GetSuspicious find followers and former followers that look like follow-bots or follow-for-follow farmers
+func (s *Server) GetSuspicious(w http.ResponseWriter, r *http.Request) {
+	var minScore int
+	var err error
+	if min := r.URL.Query().Get("min"); min != "" {
+		minScore, err = strconv.Atoi(min)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+	}
+
+	filter, err := parseTagFilter(r)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	users := []tracker.SuspiciousUser{}
+	err = s.store.View(func(tx storage.Tx) error {
+		if r.URL.Query().Get("min") == "" {
+			minScore = storage.IntSetting(tx, "suspiciousScore")
+		}
+		tags := tracker.AllTags(tx)
+		for _, u := range tracker.GetSuspiciousUsers(tx, minScore) {
+			if filter.keep(tags[u.ID]) {
+				u.Tags = tags[u.ID]
+				users = append(users, u)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(users)
+}
+
+// GetStats get churn and growth numbers, ?bucket=day|week|month&tz={time zone}
+func (s *Server) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.statsFromRequest(w, r)
+	if ok {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// GetGoals find how far the follower count is from the goal and milestones
+func (s *Server) GetGoals(w http.ResponseWriter, r *http.Request) {
+	var goals tracker.Goals
+	err := s.store.View(func(tx storage.Tx) error {
+		goals = tracker.GetGoals(tx)
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(goals)
+}
+
+// GetGrowthStats get net follower change per day, week or month
+func (s *Server) GetGrowthStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.statsFromRequest(w, r)
+	if ok {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(stats.Growth)
+	}
+}
+
+// GetChurnHourStats get unfollows per hour of the day, most unfollows first
+func (s *Server) GetChurnHourStats(w http.ResponseWriter, r *http.Request) {
+	stats, ok := s.statsFromRequest(w, r)
+	if ok {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(stats.ChurnHours)
+	}
+}
+
+// statsFromRequest computes stats for the bucket and tz query, writes the error status if it can't
+func (s *Server) statsFromRequest(w http.ResponseWriter, r *http.Request) (tracker.Stats, bool) {
+	var bucket, timezone string
+	err := s.store.View(func(tx storage.Tx) error {
+		bucket = storage.Setting(tx, "statsBucket")
+		timezone = storage.Setting(tx, "statsTimezone")
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return tracker.Stats{}, false
+	}
+	if q := r.URL.Query().Get("bucket"); q != "" {
+		bucket = q
+	}
+	if q := r.URL.Query().Get("tz"); q != "" {
+		timezone = q
+	}
+
+	stats, err := s.tracker.Stats(bucket, timezone)
+	if err != nil {
+		w.WriteHeader(400)
+		w.Write([]byte(err.Error()))
+		return tracker.Stats{}, false
+	}
+	return stats, true
+}
+
+// GetStreams find all stream sessions with follows and unfollows during and around them
+func (s *Server) GetStreams(w http.ResponseWriter, r *http.Request) {
+	var streams []tracker.StreamStat
+	err := s.store.View(func(tx storage.Tx) error {
+		streams = tracker.GetStreamStats(tx)
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(streams)
+}
+
+// GetStreamEvents find follows and unfollows that happened during or nearest to a stream session
+func (s *Server) GetStreamEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var events []tracker.StreamEvent
+	var found bool
+	err := s.store.View(func(tx storage.Tx) error {
+		found = tx.Get("streams", id) != nil
+		if found {
+			events = tracker.GetStreamEvents(tx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	if found {
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(events)
+	} else {
+		w.WriteHeader(404)
+	}
+}
+
+// GetBackup stream a consistent copy of the database
+func (s *Server) GetBackup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", storage.BackupName(s.store, time.Now())))
+	w.WriteHeader(200)
+	_, err := s.store.Backup(w)
+	if err != nil {
+		// The status is sent already, break the connection so the client doesn't keep a short file
+		fmt.Printf("[SYS] Backup download failed: %v\n", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// PostSync run jobs now, ?job= followers, following, profiles, roles or accounts, both syncs by default
+func (s *Server) PostSync(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["job"]
+	if len(names) == 0 {
+		names = []string{"followers", "following"}
+	}
+	for _, name := range names {
+		err := s.tracker.RequestSync(name)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+	json.NewEncoder(w).Encode(s.tracker.Jobs())
+}
+
+// GetSchedule list jobs with their schedule, last run and next run
+func (s *Server) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(s.tracker.Jobs())
+}
+
+// GetHealthz answer 200 while the process runs and the store can be opened
+func (s *Server) GetHealthz(w http.ResponseWriter, r *http.Request) {
+	err := s.store.View(func(tx storage.Tx) error {
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(503)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(200)
+	w.Write([]byte("ok"))
+}
+
+// GetReadyz answer 200 once a follower sync completed and Twitch accepts the token, 503 with the reasons otherwise
+func (s *Server) GetReadyz(w http.ResponseWriter, r *http.Request) {
+	reasons := s.tracker.NotReady()
+	if len(reasons) > 0 {
+		w.WriteHeader(503)
+		w.Write([]byte(strings.Join(reasons, "\n")))
+		return
+	}
+	w.WriteHeader(200)
+	w.Write([]byte("ok"))
+}
+
+// GetStatus report version, tracked channel, syncs, enrichment backlog, rate limit and next run
+func (s *Server) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.tracker.Status()
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(status)
+}
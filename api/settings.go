@@ -0,0 +1,26 @@
+package api
+
+import (
+	"github.com/devinjdawson/tut/storage"
+)
+
+func init() {
+	storage.SetDefaults(map[string]string{
+		"bindAddress":          "", // all interfaces
+		"corsOrigins":          "", // comma separated, * for any
+		"tlsCert":              "",
+		"tlsKey":               "",
+		"basicAuthUser":        "",
+		"basicAuthScope":       "read",
+		"overlayTheme":         "dark", // dark, light or transparent
+		"overlayCSS":           "",     // CSS file added to the overlay page
+		"overlayWidgets":       "alerts,recent,goal",
+		"overlayRecent":        "5",
+		"overlayAlertSeconds":  "6",
+		"overlayFollowText":    "New follower",
+		"overlayRefollowText":  "Welcome back",
+		"overlayMilestoneText": "Milestone reached",
+		"overlayGoal":          "0", // goal of the goal bar, 0 uses followerGoal, none hides the bar
+		"overlayGoalLabel":     "Follower goal",
+	})
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+	"github.com/gorilla/mux"
+)
+
+// tagFilter the ?tag= and ?ignored= parameters of the list endpoints. Ignored users are left out unless
+// ignored is set or tag asks for them.
+type tagFilter struct {
+	tag     string
+	ignored bool
+}
+
+// tagQuery the query parameters of the list endpoints
+var tagQuery = []queryParam{
+	{"tag", "only users with this tag", false},
+	{"ignored", "true to include users tagged ignore", false},
+}
+
+// parseTagFilter reads the tag filter of a request
+func parseTagFilter(r *http.Request) (tagFilter, error) {
+	f := tagFilter{tag: r.URL.Query().Get("tag")}
+	if f.tag != "" && !tracker.ValidTag(f.tag) {
+		return f, errors.New("parseTagFilter: invalid tag " + f.tag)
+	}
+	if ignored := r.URL.Query().Get("ignored"); ignored != "" {
+		var err error
+		f.ignored, err = strconv.ParseBool(ignored)
+		if err != nil {
+			return f, errors.New("parseTagFilter: ignored must be true or false")
+		}
+	}
+	return f, nil
+}
+
+// keep tells whether a user with tags passes the filter
+func (f tagFilter) keep(tags []string) bool {
+	if f.tag != "" && !tracker.HasTag(tags, f.tag) {
+		return false
+	}
+	return f.ignored || f.tag == tracker.TagIgnore || !tracker.HasTag(tags, tracker.TagIgnore)
+}
+
+// GetTags find every tagged user, ?tag= for the users with one tag
+func (s *Server) GetTags(w http.ResponseWriter, r *http.Request) {
+	tag := r.URL.Query().Get("tag")
+	if tag != "" && !tracker.ValidTag(tag) {
+		w.WriteHeader(400)
+		w.Write([]byte("GetTags: invalid tag " + tag))
+		return
+	}
+
+	users := []tracker.UserDetail{}
+	err := s.store.View(func(tx storage.Tx) error {
+		for id, tags := range tracker.AllTags(tx) {
+			if tag == "" || tracker.HasTag(tags, tag) {
+				detail, _ := tracker.GetUserDetail(tx, id)
+				users = append(users, detail)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		return
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Login < users[j].Login
+	})
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(users)
+}
+
+// PutUserTag tag a user by ID or login, Twitch is asked for logins TUT doesn't know
+func (s *Server) PutUserTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if !tracker.ValidTag(params["tag"]) {
+		w.WriteHeader(400)
+		w.Write([]byte("PutUserTag: invalid tag " + params["tag"] + ", use lowercase letters, digits, - and _"))
+		return
+	}
+	id, err := s.tracker.ResolveUser(params["id"])
+	if err != nil {
+		w.WriteHeader(404)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var tags []string
+	err = s.store.Update(func(tx storage.Tx) error {
+		err := tracker.AddTag(tx, id, params["tag"])
+		tags = tracker.GetTags(tx, id)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// DeleteUserTag remove a tag of a user by ID or login
+func (s *Server) DeleteUserTag(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var removed bool
+	tags := []string{}
+	err := s.store.Update(func(tx storage.Tx) error {
+		var err error
+		id := tracker.ResolveUserID(tx, params["id"])
+		removed, err = tracker.RemoveTag(tx, id, params["tag"])
+		tags = append(tags, tracker.GetTags(tx, id)...)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !removed {
+		w.WriteHeader(404)
+		return
+	}
+
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(tags)
+}
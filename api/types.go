@@ -0,0 +1,54 @@
+package api
+
+import "github.com/devinjdawson/tut/tracker"
+
+// User profile info
+type User struct {
+	ID              string          `json:"id"`
+	Login           string          `json:"login"`
+	Displayname     string          `json:"displayname"`
+	ProfileImageURL string          `json:"profileImageURL"`
+	FollowedAt      string          `json:"followedAt"`
+	UnfollowedAt    string          `json:"unfollowedAt"`
+	Tags            []string        `json:"tags,omitempty"`
+	Notes           []tracker.Note  `json:"notes,omitempty"`
+	Labels          []tracker.Label `json:"labels,omitempty"`
+	Roles           *tracker.Roles  `json:"roles,omitempty"`
+}
+
+// Unfollower user profile info
+type Unfollower struct {
+	ID              string          `json:"id"`
+	Login           string          `json:"login"`
+	Displayname     string          `json:"displayname"`
+	ProfileImageURL string          `json:"profileImageURL"`
+	UnfollowedAt    string          `json:"unfollowedAt"`
+	Tags            []string        `json:"tags,omitempty"`
+	Notes           []tracker.Note  `json:"notes,omitempty"`
+	Labels          []tracker.Label `json:"labels,omitempty"`
+	Roles           *tracker.Roles  `json:"roles,omitempty"`
+	Reason          string          `json:"reason,omitempty"` // banned, account_unavailable or account_closed, empty for genuine unfollows
+}
+
+// Unfollowed user profile info
+type Unfollowed struct {
+	ID              string          `json:"id"`
+	Login           string          `json:"login"`
+	Displayname     string          `json:"displayname"`
+	ProfileImageURL string          `json:"profileImageURL"`
+	UnfollowingAt   string          `json:"unfollowedAt"` // when the channel unfollowed the user, the JSON name is kept for existing clients
+	Tags            []string        `json:"tags,omitempty"`
+	Notes           []tracker.Note  `json:"notes,omitempty"`
+	Labels          []tracker.Label `json:"labels,omitempty"`
+	Roles           *tracker.Roles  `json:"roles,omitempty"`
+}
+
+// Notfollower user profile info
+type Notfollower struct {
+	ID              string `json:"id"`
+	Login           string `json:"login"`
+	Displayname     string `json:"displayname"`
+	ProfileImageURL string `json:"profileImageURL"`
+	UnfollowedAt    string `json:"unfollowedAt"`
+	UnfollowingAt   string `json:"unfollowingAt"`
+}
@@ -0,0 +1,817 @@
+// Code generated by clientgen from openapi.json. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+// AccountState as the TUT API serves it
+type AccountState struct {
+	Checks       int    `json:"checks"`
+	ClosedAt     string `json:"closedAt,omitempty"`
+	LastCheck    string `json:"lastCheck"`
+	MissingSince string `json:"missingSince"`
+	RestoredAt   string `json:"restoredAt,omitempty"`
+	// unavailable while Twitch doesn't return the account, closed once confirmed, restored when it came back.
+	Status string `json:"status"`
+}
+
+// ChurnHour as the TUT API serves it
+type ChurnHour struct {
+	Hour      int `json:"hour"`
+	Unfollows int `json:"unfollows"`
+}
+
+// Goals as the TUT API serves it
+type Goals struct {
+	Followers int `json:"followers"`
+	// The followerGoal setting, none when it is 0.
+	Goal       Milestone   `json:"goal,omitempty"`
+	Milestones []Milestone `json:"milestones"`
+	// The lowest milestone not reached, none when all are.
+	Next Milestone `json:"next,omitempty"`
+}
+
+// GrowthStat as the TUT API serves it
+type GrowthStat struct {
+	Follows int `json:"follows"`
+	Net     int `json:"net"`
+	// Unfollows of banned users and closed accounts, included in unfollows.
+	Removed   int    `json:"removed"`
+	Start     string `json:"start"`
+	Unfollows int    `json:"unfollows"`
+}
+
+// JobStatus as the TUT API serves it
+type JobStatus struct {
+	LastEnd   string `json:"lastEnd"`
+	LastError string `json:"lastError,omitempty"`
+	// ok or failed, empty before the first run.
+	LastResult  string `json:"lastResult"`
+	LastStart   string `json:"lastStart"`
+	LastSuccess string `json:"lastSuccess"`
+	Name        string `json:"name"`
+	NextRun     string `json:"nextRun"`
+	Running     bool   `json:"running"`
+	Schedule    string `json:"schedule"`
+}
+
+// Label as the TUT API serves it
+type Label struct {
+	At     string `json:"at"`
+	Author string `json:"author"`
+	Name   string `json:"name"`
+}
+
+// Milestone as the TUT API serves it
+type Milestone struct {
+	// The follower count of the milestone.
+	Followers int    `json:"followers"`
+	Goal      bool   `json:"goal"`
+	LostAt    string `json:"lostAt"`
+	// Percent of the milestone the follower count reached, at most 100.
+	Progress float64 `json:"progress"`
+	Reached  bool    `json:"reached"`
+	// When the follower count last reached it, empty if it did before TUT watched it.
+	ReachedAt string `json:"reachedAt"`
+	Remaining int    `json:"remaining"`
+}
+
+// Note as the TUT API serves it
+type Note struct {
+	Author    string `json:"author"`
+	CreatedAt string `json:"createdAt"`
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+// OverlayEvent as the TUT API serves it
+type OverlayEvent struct {
+	At      string            `json:"at"`
+	Details map[string]string `json:"details,omitempty"`
+	// Followers after the event, 0 in the recent lists of /overlay/state.
+	Followers int    `json:"followers"`
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	User      User   `json:"user"`
+}
+
+// OverlayState as the TUT API serves it
+type OverlayState struct {
+	AlertSeconds  int    `json:"alertSeconds"`
+	FollowText    string `json:"followText"`
+	Followers     int    `json:"followers"`
+	Goal          int    `json:"goal"`
+	GoalLabel     string `json:"goalLabel"`
+	MilestoneText string `json:"milestoneText"`
+	// How many recent followers and unfollowers the overlay lists.
+	Recent            int            `json:"recent"`
+	RecentFollowers   []OverlayEvent `json:"recentFollowers"`
+	RecentUnfollowers []OverlayEvent `json:"recentUnfollowers"`
+	RefollowText      string         `json:"refollowText"`
+}
+
+// Relationship as the TUT API serves it
+type Relationship struct {
+	FollowedAt    string `json:"followedAt"`
+	Follower      bool   `json:"follower"`
+	Following     bool   `json:"following"`
+	FollowingAt   string `json:"followingAt"`
+	RefollowedAt  string `json:"refollowedAt"`
+	RefollowingAt string `json:"refollowingAt"`
+	UnfollowedAt  string `json:"unfollowedAt"`
+	UnfollowingAt string `json:"unfollowingAt"`
+}
+
+// RoleSync as the TUT API serves it
+type RoleSync struct {
+	// Why the last read failed, usually a scope the token lacks.
+	Error string `json:"error,omitempty"`
+	Kind  string `json:"kind"`
+	// When the kind of role was last read, empty if Twitch never answered.
+	LastSync string `json:"lastSync"`
+	Scope    string `json:"scope"`
+	Users    int    `json:"users"`
+}
+
+// Roles as the TUT API serves it
+type Roles struct {
+	// When a timeout ends, empty for permanent bans.
+	BanExpiresAt string `json:"banExpiresAt,omitempty"`
+	BanReason    string `json:"banReason,omitempty"`
+	Banned       bool   `json:"banned,omitempty"`
+	Moderator    bool   `json:"moderator,omitempty"`
+	SubGift      bool   `json:"subGift,omitempty"`
+	// 1, 2 or 3, empty for users who don't subscribe.
+	SubTier string `json:"subTier,omitempty"`
+	Vip     bool   `json:"vip,omitempty"`
+}
+
+// Stats as the TUT API serves it
+type Stats struct {
+	AvgFollowDurationSeconds float64 `json:"avgFollowDurationSeconds"`
+	// day, week or month.
+	Bucket              string       `json:"bucket"`
+	ChurnHours          []ChurnHour  `json:"churnHours"`
+	ComputedAt          string       `json:"computedAt"`
+	Followers           int          `json:"followers"`
+	Follows             int          `json:"follows"`
+	GenuineUnfollowRate float64      `json:"genuineUnfollowRate"`
+	GenuineUnfollows    int          `json:"genuineUnfollows"`
+	Growth              []GrowthStat `json:"growth"`
+	RefollowRatio       float64      `json:"refollowRatio"`
+	Refollows           int          `json:"refollows"`
+	Streams             []StreamStat `json:"streams"`
+	Timezone            string       `json:"timezone"`
+	UnfollowRate        float64      `json:"unfollowRate"`
+	Unfollows           int          `json:"unfollows"`
+	UnfollowsBanned     int          `json:"unfollowsBanned"`
+	// Unfollowers whose accounts were deleted or suspended, confirmed or not.
+	UnfollowsClosed int `json:"unfollowsClosed"`
+}
+
+// Status as the TUT API serves it
+type Status struct {
+	BaselineAt string `json:"baselineAt"`
+	Channel    string `json:"channel"`
+	ChannelID  string `json:"channelID"`
+	// Followers and following whose profile is not fetched yet.
+	EnrichmentBacklog int          `json:"enrichmentBacklog"`
+	Followers         int          `json:"followers"`
+	Following         int          `json:"following"`
+	Jobs              []JobStatus  `json:"jobs"`
+	NextRun           string       `json:"nextRun"`
+	Roles             []RoleSync   `json:"roles"`
+	StartedAt         string       `json:"startedAt"`
+	Syncs             []SyncStatus `json:"syncs"`
+	Twitch            TwitchState  `json:"twitch"`
+	Version           string       `json:"version"`
+}
+
+// StreamEvent as the TUT API serves it
+type StreamEvent struct {
+	At          string `json:"at"`
+	Displayname string `json:"displayname"`
+	Login       string `json:"login"`
+	// during when the event happened while live, otherwise before or after the nearest stream.
+	Relation string `json:"relation"`
+	StreamID string `json:"streamID"`
+	Type     string `json:"type"`
+	UserID   string `json:"userID"`
+}
+
+// StreamStat as the TUT API serves it
+type StreamStat struct {
+	Category        string `json:"category"`
+	EndedAt         string `json:"endedAt"`
+	FollowsAround   int    `json:"followsAround"`
+	FollowsDuring   int    `json:"followsDuring"`
+	ID              string `json:"id"`
+	StartedAt       string `json:"startedAt"`
+	Title           string `json:"title"`
+	UnfollowsAround int    `json:"unfollowsAround"`
+	UnfollowsDuring int    `json:"unfollowsDuring"`
+}
+
+// SuspiciousUser as the TUT API serves it
+type SuspiciousUser struct {
+	CreatedAt       string   `json:"createdAt"`
+	Displayname     string   `json:"displayname"`
+	FollowedAt      string   `json:"followedAt"`
+	ID              string   `json:"id"`
+	Login           string   `json:"login"`
+	ProfileImageURL string   `json:"profileImageURL"`
+	Reasons         []string `json:"reasons"`
+	Score           int      `json:"score"`
+	Tags            []string `json:"tags,omitempty"`
+	UnfollowedAt    string   `json:"unfollowedAt"`
+}
+
+// SyncStatus as the TUT API serves it
+type SyncStatus struct {
+	// When every page of the list was last read and diffed.
+	LastComplete string `json:"lastComplete"`
+	LastEnd      string `json:"lastEnd"`
+	LastError    string `json:"lastError,omitempty"`
+	LastStart    string `json:"lastStart"`
+	List         string `json:"list"`
+	Pages        int    `json:"pages"`
+	Running      bool   `json:"running"`
+	Users        int    `json:"users"`
+}
+
+// Timeline as the TUT API serves it
+type Timeline struct {
+	Entries []TimelineEntry `json:"entries"`
+	User    UserDetail      `json:"user"`
+}
+
+// TimelineEntry as the TUT API serves it
+type TimelineEntry struct {
+	At      string            `json:"at"`
+	Details map[string]string `json:"details,omitempty"`
+	// events when TUT saw it happen, otherwise the relationship list it is derived from.
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// TwitchState as the TUT API serves it
+type TwitchState struct {
+	LastResponseAt     string `json:"lastResponseAt"`
+	LastStatus         int    `json:"lastStatus"`
+	RateLimit          int    `json:"rateLimit"`
+	RateLimitRemaining int    `json:"rateLimitRemaining"`
+	RateLimitResetAt   string `json:"rateLimitResetAt"`
+	// false once Twitch rejected ClientID or OAuth token.
+	TokenValid bool `json:"tokenValid"`
+}
+
+// Unfollowed as the TUT API serves it
+type Unfollowed struct {
+	Displayname     string   `json:"displayname"`
+	ID              string   `json:"id"`
+	Labels          []Label  `json:"labels,omitempty"`
+	Login           string   `json:"login"`
+	Notes           []Note   `json:"notes,omitempty"`
+	ProfileImageURL string   `json:"profileImageURL"`
+	Roles           Roles    `json:"roles,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	// When the tracked channel unfollowed the user. Named unfollowedAt, not unfollowingAt, for compatibility.
+	UnfollowedAt string `json:"unfollowedAt"`
+}
+
+// Unfollower as the TUT API serves it
+type Unfollower struct {
+	Displayname     string  `json:"displayname"`
+	ID              string  `json:"id"`
+	Labels          []Label `json:"labels,omitempty"`
+	Login           string  `json:"login"`
+	Notes           []Note  `json:"notes,omitempty"`
+	ProfileImageURL string  `json:"profileImageURL"`
+	// banned, account_unavailable or account_closed when the unfollow wasn't the user's choice.
+	Reason string   `json:"reason,omitempty"`
+	Roles  Roles    `json:"roles,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	// When the user unfollowed the tracked channel.
+	UnfollowedAt string `json:"unfollowedAt"`
+}
+
+// User as the TUT API serves it
+type User struct {
+	Displayname     string   `json:"displayname"`
+	FollowedAt      string   `json:"followedAt"`
+	ID              string   `json:"id"`
+	Labels          []Label  `json:"labels,omitempty"`
+	Login           string   `json:"login"`
+	Notes           []Note   `json:"notes,omitempty"`
+	ProfileImageURL string   `json:"profileImageURL"`
+	Roles           Roles    `json:"roles,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	// When the user last unfollowed, empty if they never did.
+	UnfollowedAt string `json:"unfollowedAt"`
+}
+
+// UserDetail as the TUT API serves it
+type UserDetail struct {
+	Account         AccountState `json:"account,omitempty"`
+	Displayname     string       `json:"displayname"`
+	ID              string       `json:"id"`
+	Labels          []Label      `json:"labels,omitempty"`
+	Login           string       `json:"login"`
+	Notes           []Note       `json:"notes,omitempty"`
+	ProfileImageURL string       `json:"profileImageURL"`
+	Relationship    Relationship `json:"relationship"`
+	Roles           Roles        `json:"roles,omitempty"`
+	Tags            []string     `json:"tags,omitempty"`
+}
+
+// UserNotes as the TUT API serves it
+type UserNotes struct {
+	Displayname string  `json:"displayname"`
+	ID          string  `json:"id"`
+	Labels      []Label `json:"labels"`
+	Login       string  `json:"login"`
+	Notes       []Note  `json:"notes"`
+}
+
+// DeleteUserLabel calls DELETE /user/{id}/labels/{label}: Remove a label of a user
+// Needs the admin scope.
+func (c *Client) DeleteUserLabel(ctx context.Context, id string, label string) (UserNotes, error) {
+	query := url.Values{}
+	var out UserNotes
+	err := c.doJSON(ctx, "DELETE", "/user/"+url.PathEscape(id)+"/labels/"+url.PathEscape(label), query, &out)
+	return out, err
+}
+
+// DeleteUserNote calls DELETE /user/{id}/notes/{note}: Delete a note
+// Needs the admin scope.
+func (c *Client) DeleteUserNote(ctx context.Context, id string, note string) (UserNotes, error) {
+	query := url.Values{}
+	var out UserNotes
+	err := c.doJSON(ctx, "DELETE", "/user/"+url.PathEscape(id)+"/notes/"+url.PathEscape(note), query, &out)
+	return out, err
+}
+
+// DeleteUserTag calls DELETE /user/{id}/tags/{tag}: Remove a tag of a user by ID or login
+// Needs the admin scope.
+func (c *Client) DeleteUserTag(ctx context.Context, id string, tag string) ([]string, error) {
+	query := url.Values{}
+	var out []string
+	err := c.doJSON(ctx, "DELETE", "/user/"+url.PathEscape(id)+"/tags/"+url.PathEscape(tag), query, &out)
+	return out, err
+}
+
+// GetBackup calls GET /admin/backup: Download a consistent copy of the database
+// Needs the admin scope.
+func (c *Client) GetBackup(ctx context.Context) (io.ReadCloser, error) {
+	query := url.Values{}
+	return c.doStream(ctx, "GET", "/admin/backup", query)
+}
+
+// GetChurnHourStats calls GET /stats/churn-hours: Unfollows per hour of the day
+// Needs the read scope.
+// bucket: day, week or month, defaults to the statsBucket setting
+// tz: IANA time zone of the buckets, defaults to the statsTimezone setting
+func (c *Client) GetChurnHourStats(ctx context.Context, bucket string, tz string) ([]ChurnHour, error) {
+	query := url.Values{}
+	if bucket != "" {
+		query.Set("bucket", bucket)
+	}
+	if tz != "" {
+		query.Set("tz", tz)
+	}
+	var out []ChurnHour
+	err := c.doJSON(ctx, "GET", "/stats/churn-hours", query, &out)
+	return out, err
+}
+
+// GetFollowers calls GET /followers: Current followers
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetFollowers(ctx context.Context, tag string, ignored string) ([]User, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []User
+	err := c.doJSON(ctx, "GET", "/followers", query, &out)
+	return out, err
+}
+
+// GetFollowersID calls GET /followersID: IDs of current followers
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetFollowersID(ctx context.Context, tag string, ignored string) ([]int, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []int
+	err := c.doJSON(ctx, "GET", "/followersID", query, &out)
+	return out, err
+}
+
+// GetFollowing calls GET /following: Users the channel follows
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetFollowing(ctx context.Context, tag string, ignored string) ([]User, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []User
+	err := c.doJSON(ctx, "GET", "/following", query, &out)
+	return out, err
+}
+
+// GetFollowingID calls GET /followingID: IDs of users the channel follows
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetFollowingID(ctx context.Context, tag string, ignored string) ([]int, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []int
+	err := c.doJSON(ctx, "GET", "/followingID", query, &out)
+	return out, err
+}
+
+// GetGoals calls GET /goals: Progress towards followerGoal and the follower milestones
+// Needs the read scope.
+func (c *Client) GetGoals(ctx context.Context) (Goals, error) {
+	query := url.Values{}
+	var out Goals
+	err := c.doJSON(ctx, "GET", "/goals", query, &out)
+	return out, err
+}
+
+// GetGrowthStats calls GET /stats/growth: Follows, unfollows and net change per bucket
+// Needs the read scope.
+// bucket: day, week or month, defaults to the statsBucket setting
+// tz: IANA time zone of the buckets, defaults to the statsTimezone setting
+func (c *Client) GetGrowthStats(ctx context.Context, bucket string, tz string) ([]GrowthStat, error) {
+	query := url.Values{}
+	if bucket != "" {
+		query.Set("bucket", bucket)
+	}
+	if tz != "" {
+		query.Set("tz", tz)
+	}
+	var out []GrowthStat
+	err := c.doJSON(ctx, "GET", "/stats/growth", query, &out)
+	return out, err
+}
+
+// GetHealthz calls GET /healthz: 200 while TUT runs and the store can be opened
+func (c *Client) GetHealthz(ctx context.Context) (string, error) {
+	query := url.Values{}
+	return c.doText(ctx, "GET", "/healthz", query)
+}
+
+// GetOpenAPI calls GET /openapi.json: This OpenAPI document
+// Needs the read scope.
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]interface{}, error) {
+	query := url.Values{}
+	var out map[string]interface{}
+	err := c.doJSON(ctx, "GET", "/openapi.json", query, &out)
+	return out, err
+}
+
+// GetOverlayState calls GET /overlay/state: Follower count, goal and recent events the overlay shows when it loads
+// Needs the read scope.
+func (c *Client) GetOverlayState(ctx context.Context) (OverlayState, error) {
+	query := url.Values{}
+	var out OverlayState
+	err := c.doJSON(ctx, "GET", "/overlay/state", query, &out)
+	return out, err
+}
+
+// GetReadyz calls GET /readyz: 200 once a follower sync completed and Twitch accepts the token
+func (c *Client) GetReadyz(ctx context.Context) (string, error) {
+	query := url.Values{}
+	return c.doText(ctx, "GET", "/readyz", query)
+}
+
+// GetRefollowers calls GET /refollowers: Followers that unfollowed before
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetRefollowers(ctx context.Context, tag string, ignored string) ([]User, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []User
+	err := c.doJSON(ctx, "GET", "/refollowers", query, &out)
+	return out, err
+}
+
+// GetRefollowing calls GET /refollowing: Users the channel follows again after unfollowing them
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetRefollowing(ctx context.Context, tag string, ignored string) ([]User, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []User
+	err := c.doJSON(ctx, "GET", "/refollowing", query, &out)
+	return out, err
+}
+
+// GetSchedule calls GET /schedule: Jobs with their schedule, last run and next run
+// Needs the read scope.
+func (c *Client) GetSchedule(ctx context.Context) ([]JobStatus, error) {
+	query := url.Values{}
+	var out []JobStatus
+	err := c.doJSON(ctx, "GET", "/schedule", query, &out)
+	return out, err
+}
+
+// GetStats calls GET /stats: Churn and growth numbers
+// Needs the read scope.
+// bucket: day, week or month, defaults to the statsBucket setting
+// tz: IANA time zone of the buckets, defaults to the statsTimezone setting
+func (c *Client) GetStats(ctx context.Context, bucket string, tz string) (Stats, error) {
+	query := url.Values{}
+	if bucket != "" {
+		query.Set("bucket", bucket)
+	}
+	if tz != "" {
+		query.Set("tz", tz)
+	}
+	var out Stats
+	err := c.doJSON(ctx, "GET", "/stats", query, &out)
+	return out, err
+}
+
+// GetStatus calls GET /status: Version, tracked channel, syncs, enrichment backlog and rate limit
+// Needs the read scope.
+func (c *Client) GetStatus(ctx context.Context) (Status, error) {
+	query := url.Values{}
+	var out Status
+	err := c.doJSON(ctx, "GET", "/status", query, &out)
+	return out, err
+}
+
+// GetStreamEvents calls GET /streams/{id}/events: Follows and unfollows of a stream session
+// Needs the read scope.
+func (c *Client) GetStreamEvents(ctx context.Context, id string) ([]StreamEvent, error) {
+	query := url.Values{}
+	var out []StreamEvent
+	err := c.doJSON(ctx, "GET", "/streams/"+url.PathEscape(id)+"/events", query, &out)
+	return out, err
+}
+
+// GetStreams calls GET /streams: Stream sessions with follows and unfollows during and around them
+// Needs the read scope.
+func (c *Client) GetStreams(ctx context.Context) ([]StreamStat, error) {
+	query := url.Values{}
+	var out []StreamStat
+	err := c.doJSON(ctx, "GET", "/streams", query, &out)
+	return out, err
+}
+
+// GetSuspicious calls GET /suspicious: Followers and former followers that look like bots
+// Needs the read scope.
+// min: minimum score, defaults to the suspiciousScore setting
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetSuspicious(ctx context.Context, min string, tag string, ignored string) ([]SuspiciousUser, error) {
+	query := url.Values{}
+	if min != "" {
+		query.Set("min", min)
+	}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []SuspiciousUser
+	err := c.doJSON(ctx, "GET", "/suspicious", query, &out)
+	return out, err
+}
+
+// GetTags calls GET /tags: Tagged users
+// Needs the read scope.
+// tag: only users with this tag
+func (c *Client) GetTags(ctx context.Context, tag string) ([]UserDetail, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	var out []UserDetail
+	err := c.doJSON(ctx, "GET", "/tags", query, &out)
+	return out, err
+}
+
+// GetUnfollowers calls GET /unfollowers: Users that unfollowed the channel
+// Needs the read scope.
+// reason: genuine, banned, account_unavailable or account_closed
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetUnfollowers(ctx context.Context, reason string, tag string, ignored string) ([]Unfollower, error) {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []Unfollower
+	err := c.doJSON(ctx, "GET", "/unfollowers", query, &out)
+	return out, err
+}
+
+// GetUnfollowing calls GET /unfollowing: Users the channel unfollowed
+// Needs the read scope.
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) GetUnfollowing(ctx context.Context, tag string, ignored string) ([]Unfollowed, error) {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []Unfollowed
+	err := c.doJSON(ctx, "GET", "/unfollowing", query, &out)
+	return out, err
+}
+
+// GetUser calls GET /user/{id}: Profile and relationship of a user
+// Needs the read scope.
+func (c *Client) GetUser(ctx context.Context, id string) (UserDetail, error) {
+	query := url.Values{}
+	var out UserDetail
+	err := c.doJSON(ctx, "GET", "/user/"+url.PathEscape(id), query, &out)
+	return out, err
+}
+
+// GetUserByLogin calls GET /user/by-login/{login}: Profile and relationship of a user by login
+// Needs the read scope.
+func (c *Client) GetUserByLogin(ctx context.Context, login string) (UserDetail, error) {
+	query := url.Values{}
+	var out UserDetail
+	err := c.doJSON(ctx, "GET", "/user/by-login/"+url.PathEscape(login), query, &out)
+	return out, err
+}
+
+// GetUserNotes calls GET /user/{id}/notes: Notes and labels of a user by ID or login
+// Needs the read scope.
+func (c *Client) GetUserNotes(ctx context.Context, id string) (UserNotes, error) {
+	query := url.Values{}
+	var out UserNotes
+	err := c.doJSON(ctx, "GET", "/user/"+url.PathEscape(id)+"/notes", query, &out)
+	return out, err
+}
+
+// GetUserTimeline calls GET /user/{id}/timeline: Everything TUT knows about a user, by ID or login
+// Needs the read scope.
+func (c *Client) GetUserTimeline(ctx context.Context, id string) (Timeline, error) {
+	query := url.Values{}
+	var out Timeline
+	err := c.doJSON(ctx, "GET", "/user/"+url.PathEscape(id)+"/timeline", query, &out)
+	return out, err
+}
+
+// PostSync calls POST /admin/sync: Run jobs now
+// Needs the admin scope.
+// job: followers, following, profiles, roles or accounts, may repeat, both syncs by default
+func (c *Client) PostSync(ctx context.Context, job string) ([]JobStatus, error) {
+	query := url.Values{}
+	if job != "" {
+		query.Set("job", job)
+	}
+	var out []JobStatus
+	err := c.doJSON(ctx, "POST", "/admin/sync", query, &out)
+	return out, err
+}
+
+// PostUserNote calls POST /user/{id}/notes: Add a note to a user by ID or login
+// Needs the admin scope.
+// text: text of the note
+// author: who wrote it, only used without API keys or basic auth, which name the author themselves
+func (c *Client) PostUserNote(ctx context.Context, id string, text string, author string) (Note, error) {
+	query := url.Values{}
+	if text != "" {
+		query.Set("text", text)
+	}
+	if author != "" {
+		query.Set("author", author)
+	}
+	var out Note
+	err := c.doJSON(ctx, "POST", "/user/"+url.PathEscape(id)+"/notes", query, &out)
+	return out, err
+}
+
+// PutUserLabel calls PUT /user/{id}/labels/{label}: Label a user by ID or login, e.g. sub since 2019
+// Needs the admin scope.
+// author: who wrote it, only used without API keys or basic auth, which name the author themselves
+func (c *Client) PutUserLabel(ctx context.Context, id string, label string, author string) (UserNotes, error) {
+	query := url.Values{}
+	if author != "" {
+		query.Set("author", author)
+	}
+	var out UserNotes
+	err := c.doJSON(ctx, "PUT", "/user/"+url.PathEscape(id)+"/labels/"+url.PathEscape(label), query, &out)
+	return out, err
+}
+
+// PutUserNote calls PUT /user/{id}/notes/{note}: Change the text of a note
+// Needs the admin scope.
+// text: text of the note
+// author: who wrote it, only used without API keys or basic auth, which name the author themselves
+func (c *Client) PutUserNote(ctx context.Context, id string, note string, text string, author string) (Note, error) {
+	query := url.Values{}
+	if text != "" {
+		query.Set("text", text)
+	}
+	if author != "" {
+		query.Set("author", author)
+	}
+	var out Note
+	err := c.doJSON(ctx, "PUT", "/user/"+url.PathEscape(id)+"/notes/"+url.PathEscape(note), query, &out)
+	return out, err
+}
+
+// PutUserTag calls PUT /user/{id}/tags/{tag}: Tag a user by ID or login, ignore, watch, vip or any other tag
+// Needs the admin scope.
+func (c *Client) PutUserTag(ctx context.Context, id string, tag string) ([]string, error) {
+	query := url.Values{}
+	var out []string
+	err := c.doJSON(ctx, "PUT", "/user/"+url.PathEscape(id)+"/tags/"+url.PathEscape(tag), query, &out)
+	return out, err
+}
+
+// SearchNotes calls GET /notes: Users with notes or labels
+// Needs the read scope.
+// label: only users with this label, ignoring case
+// q: only users whose notes or labels contain q, ignoring case
+func (c *Client) SearchNotes(ctx context.Context, label string, q string) ([]UserNotes, error) {
+	query := url.Values{}
+	if label != "" {
+		query.Set("label", label)
+	}
+	if q != "" {
+		query.Set("q", q)
+	}
+	var out []UserNotes
+	err := c.doJSON(ctx, "GET", "/notes", query, &out)
+	return out, err
+}
+
+// SearchUsers calls GET /users/search: Users whose login starts with q
+// Needs the read scope.
+// q: login prefix
+// tag: only users with this tag
+// ignored: true to include users tagged ignore
+func (c *Client) SearchUsers(ctx context.Context, q string, tag string, ignored string) ([]UserDetail, error) {
+	query := url.Values{}
+	if q != "" {
+		query.Set("q", q)
+	}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if ignored != "" {
+		query.Set("ignored", ignored)
+	}
+	var out []UserDetail
+	err := c.doJSON(ctx, "GET", "/users/search", query, &out)
+	return out, err
+}
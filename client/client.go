@@ -0,0 +1,114 @@
+// Package client calls the HTTP API of TUT, Twitch Unfollow Tracker.
+//
+// Types and methods in api_gen.go are generated from openapi.json, run go generate after changing the API.
+//
+//	c := client.New("http://localhost:8080", os.Getenv("TUT_API_KEY"))
+//	unfollowers, err := c.GetUnfollowers(ctx, "", "")
+package client
+
+//go:generate sh -c "cd .. && go run ./cmd/tut openapi > openapi.json"
+//go:generate go run ../internal/clientgen ../openapi.json api_gen.go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client of one TUT instance
+type Client struct {
+	// BaseURL of TUT, e.g. http://localhost:8080
+	BaseURL string
+	// APIKey sent as X-API-Key, created with "tut apikey add", empty for an open API
+	APIKey string
+	// Username and Password for basic auth, used when APIKey is empty
+	Username string
+	Password string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Error a response with a status other than 2xx
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("tut: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("tut: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// New creates a client of the TUT at baseURL
+func New(baseURL string, apiKey string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), APIKey: apiKey}
+}
+
+// do sends a request, the caller closes the body of a 2xx response
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values) (*http.Response, error) {
+	u := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// doJSON sends a request and decodes the JSON response into out
+func (c *Client) doJSON(ctx context.Context, method string, path string, query url.Values, out interface{}) error {
+	resp, err := c.do(ctx, method, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// doText sends a request and returns the text response
+func (c *Client) doText(ctx context.Context, method string, path string, query url.Values) (string, error) {
+	resp, err := c.do(ctx, method, path, query)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// doStream sends a request and returns the response body, which the caller closes
+func (c *Client) doStream(ctx context.Context, method string, path string, query url.Values) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, method, path, query)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
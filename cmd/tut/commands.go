@@ -0,0 +1,641 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/api"
+	"github.com/devinjdawson/tut/notify"
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+type command struct {
+	usage string
+	run   func(args []string)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"timeline":      {"timeline <id|login>  print everything TUT knows about a user", runTimeline},
+		"config":        {"config [key [value]]  show or change settings", runConfig},
+		"backup":        {"backup [file|-]  write a copy of the database, to stdout with -", runBackup},
+		"restore":       {"restore <file>  replace the database with a backup, TUT must be stopped", runRestore},
+		"import":        {"import <followers|unfollowers> <file|->  merge a CSV or JSON export into the history", runImport},
+		"apikey":        {"apikey <add <name> <read|admin>|list|remove <name>>  manage API keys of the HTTP API", runAPIKey},
+		"basicauth":     {"basicauth <user [read|admin]|off>  set or remove the basic auth user of the HTTP API, asks for the password", runBasicAuth},
+		"migrate-store": {"migrate-store <from> <to>  copy everything into another store, e.g. bolt:TUT.db sqlite:TUT.sqlite", runMigrateStore},
+		"openapi":       {"openapi  print the OpenAPI document of the HTTP API", runOpenAPI},
+		"email":         {"email <test|digest|preview>  send a test email, send the digest now, or print it without sending", runEmail},
+		"hook":          {"hook test [follow|refollow|unfollow]  run hookCommand with a made-up event and wait for it", runHook},
+		"chat":          {"chat <test [message]|preview>  post a message in chat, or print the announcements of made-up users", runChat},
+		"note":          {"note <add <id|login> <text>|edit <id|login> <note> <text>|remove <id|login> <note>|list <id|login>>  keep notes on users", runNote},
+		"label":         {"label <add|remove <id|login> <label>|list [label]>  label users, e.g. \"sub since 2019\", or list labeled users", runLabel},
+		"tag":           {"tag <add|remove <id|login> <tag>|list [tag]>  tag users as ignore, watch, vip or anything else, or list them", runTag},
+	}
+}
+
+// storelessCommands run without opening the store
+var storelessCommands = map[string]bool{"openapi": true}
+
+// runCommand runs a subcommand instead of the tracker, e.g. "tut timeline someone"
+func runCommand(args []string) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		printUsage()
+		os.Exit(2)
+	}
+	cmd.run(args[1:])
+}
+
+func printUsage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: tut [options] [command]\n\nWithout command TUT starts tracking.\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  tut %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nOptions:\n")
+	flag.PrintDefaults()
+}
+
+func runTimeline(args []string) {
+	if len(args) != 1 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	var timeline tracker.Timeline
+	var found bool
+	store.View(func(tx storage.Tx) error {
+		timeline, found = tracker.GetTimeline(tx, tracker.ResolveUserID(tx, args[0]))
+		return nil
+	})
+	if !found {
+		fmt.Printf("[SYS] TUT knows nothing about %s\n", args[0])
+		os.Exit(1)
+	}
+
+	u := timeline.User
+	fmt.Printf("[USER] %s (%s) [%s]\n", u.Displayname, u.Login, u.ID)
+	for _, entry := range timeline.Entries {
+		var details []string
+		for k, v := range entry.Details {
+			details = append(details, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(details)
+		fmt.Println(strings.TrimSpace(fmt.Sprintf("[%s][%s] %s %s", entry.At, strings.ToUpper(entry.Type), entry.Source, strings.Join(details, " "))))
+	}
+}
+
+func runConfig(args []string) {
+	if len(args) > 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		if !storage.IsSetting(args[0]) {
+			fmt.Printf("[SYS] Unknown setting %s\n", args[0])
+			os.Exit(2)
+		}
+	}
+
+	if len(args) == 2 {
+		err := store.Update(func(tx storage.Tx) error {
+			return tx.SetConfig(args[0], args[1])
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var keys []string
+	if len(args) > 0 {
+		keys = append(keys, args[0])
+	} else {
+		keys = storage.Settings()
+	}
+	store.View(func(tx storage.Tx) error {
+		for _, key := range keys {
+			fmt.Printf("%s = %s\n", key, storage.Setting(tx, key))
+		}
+		return nil
+	})
+}
+
+func runBackup(args []string) {
+	if len(args) > 1 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	if len(args) == 1 && args[0] == "-" {
+		_, err := store.Backup(os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		store.View(func(tx storage.Tx) error {
+			path = filepath.Join(storage.Setting(tx, "backupDir"), storage.BackupName(store, time.Now()))
+			return nil
+		})
+	}
+	err := storage.BackupToFile(store, path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Backed up database to %s\n", path)
+}
+
+func runRestore(args []string) {
+	if len(args) != 1 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	previous, err := storage.RestoreBackup(store, args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Restored database from %s, the replaced database was saved as %s\n", args[0], previous)
+}
+
+func runMigrateStore(args []string) {
+	if len(args) != 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	fromKind, fromPath := storage.ParseSpec(args[0])
+	toKind, toPath := storage.ParseSpec(args[1])
+	if _, err := os.Stat(fromPath); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := os.Stat(toPath); err == nil {
+		fmt.Printf("[SYS] %s already exists, TUT only migrates into a new store\n", toPath)
+		os.Exit(1)
+	}
+
+	from, err := storage.Open(fromKind, fromPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer from.Close()
+	to, err := storage.Open(toKind, toPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer to.Close()
+
+	err = storage.Copy(from, to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Copied %s:%s into %s:%s, start TUT with -store %s -db %s to use it\n", fromKind, fromPath, toKind, toPath, toKind, toPath)
+}
+
+func runImport(args []string) {
+	if len(args) != 2 || (args[0] != storage.ListFollowers && args[0] != storage.ListUnfollowers) {
+		printUsage()
+		os.Exit(2)
+	}
+
+	in := os.Stdin
+	if args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	records, err := tracker.ReadImport(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var result tracker.ImportResult
+	err = store.Update(func(tx storage.Tx) error {
+		result = tracker.ImportRecords(tx, args[0], records)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Imported %s: %d added, %d already known, %d user profiles, %d skipped without ID or time\n", args[0], result.Added, result.Merged, result.Users, result.Skipped)
+}
+
+func runAPIKey(args []string) {
+	switch {
+	case len(args) == 3 && args[0] == "add":
+		key, err := api.NewAPIKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = store.Update(func(tx storage.Tx) error {
+			return api.PutAPIKey(tx, args[1], args[2], key)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Added %s API key %s, it is not shown again:\n%s\n", args[2], args[1], key)
+	case len(args) == 1 && args[0] == "list":
+		store.View(func(tx storage.Tx) error {
+			keys := api.APIKeyScopes(tx)
+			var names []string
+			for name := range keys {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("%s = %s\n", name, keys[name])
+			}
+			return nil
+		})
+	case len(args) == 2 && args[0] == "remove":
+		err := store.Update(func(tx storage.Tx) error {
+			return tx.DeleteConfig(api.APIKeyPrefix + args[1])
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Removed API key %s\n", args[1])
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func runBasicAuth(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	if len(args) == 1 && args[0] == "off" {
+		err := store.Update(func(tx storage.Tx) error {
+			err := tx.DeleteConfig("basicAuthUser")
+			if err != nil {
+				return err
+			}
+			return tx.DeleteConfig("basicAuthPassword")
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Removed basic auth\n")
+		return
+	}
+
+	scope := api.ScopeRead
+	if len(args) == 2 {
+		scope = args[1]
+	}
+	if !api.ValidScope(scope) {
+		fmt.Printf("[SYS] Unknown scope %s, use read or admin\n", scope)
+		os.Exit(2)
+	}
+
+	fmt.Printf("Enter password for %s: ", args[0])
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	password := scanner.Text()
+	if password == "" {
+		fmt.Printf("[SYS] Basic auth needs a password\n")
+		os.Exit(2)
+	}
+	hash, err := api.HashPassword(password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = store.Update(func(tx storage.Tx) error {
+		err := tx.SetConfig("basicAuthUser", args[0])
+		if err != nil {
+			return err
+		}
+		err = tx.SetConfig("basicAuthScope", scope)
+		if err != nil {
+			return err
+		}
+		return tx.SetConfig("basicAuthPassword", hash)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Basic auth user %s has %s access\n", args[0], scope)
+}
+
+func runOpenAPI(args []string) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(api.OpenAPISpec())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runEmail(args []string) {
+	if len(args) != 1 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	mail := notify.NewEmail(store)
+	switch args[0] {
+	case "test":
+		err := mail.SendTest()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Sent test email\n")
+	case "digest":
+		err := mail.SendDigest("")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Sent email digest, the next one starts from now\n")
+	case "preview":
+		subject, body, err := mail.RenderDigest("")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Subject: %s\n\n%s", subject, body)
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func runHook(args []string) {
+	if len(args) < 1 || len(args) > 2 || args[0] != "test" {
+		printUsage()
+		os.Exit(2)
+	}
+	eventType := storage.EventFollow
+	if len(args) == 2 {
+		eventType = args[1]
+	}
+
+	e := tracker.Event{Type: eventType, UserID: "0", Login: "tut_test", Displayname: "TUT_Test", At: time.Now().UTC().Format(time.RFC3339)}
+	output, err := notify.NewExec(store).Run(e)
+	os.Stdout.Write(output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("[SYS] Ran hook of %s\n", eventType)
+}
+
+func runChat(args []string) {
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	chat := notify.NewChat(store)
+	switch args[0] {
+	case "test":
+		message := "TUT can post in this chat"
+		if len(args) > 1 {
+			message = strings.Join(args[1:], " ")
+		}
+		err := chat.Say(message)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Posted %q\n", message)
+	case "preview":
+		messages, err := chat.Preview()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, message := range messages {
+			fmt.Println(message)
+		}
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+// resolveUser finds the user ID of an ID or login, asking Twitch with the stored client ID and token for logins
+// TUT doesn't know
+func resolveUser(idOrLogin string) string {
+	var conf tracker.Config
+	store.View(func(tx storage.Tx) error {
+		conf.ClientID, _ = tx.Config("clientID")
+		conf.OAuth, _ = tx.Config("oauth")
+		conf.Username, _ = tx.Config("username")
+		conf.UserID, _ = tx.Config("userID")
+		return nil
+	})
+	uid, err := tracker.New(store, conf).ResolveUser(idOrLogin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return uid
+}
+
+// cliAuthor names who wrote notes and labels from the command line
+func cliAuthor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user + " (cli)"
+	}
+	return "cli"
+}
+
+func runTag(args []string) {
+	switch {
+	case len(args) == 3 && args[0] == "add":
+		uid := resolveUser(args[1])
+		err := store.Update(func(tx storage.Tx) error {
+			return tracker.AddTag(tx, uid, args[2])
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Tagged %s [%s] %s\n", args[1], uid, args[2])
+	case len(args) == 3 && args[0] == "remove":
+		var removed bool
+		err := store.Update(func(tx storage.Tx) error {
+			var err error
+			removed, err = tracker.RemoveTag(tx, tracker.ResolveUserID(tx, args[1]), args[2])
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !removed {
+			fmt.Printf("[SYS] %s has no tag %s\n", args[1], args[2])
+			os.Exit(1)
+		}
+		fmt.Printf("[SYS] Removed tag %s of %s\n", args[2], args[1])
+	case (len(args) == 1 || len(args) == 2) && args[0] == "list":
+		store.View(func(tx storage.Tx) error {
+			var ids []string
+			all := tracker.AllTags(tx)
+			for id := range all {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				if len(args) == 2 && !tracker.HasTag(all[id], args[1]) {
+					continue
+				}
+				detail, _ := tracker.GetUserDetail(tx, id)
+				fmt.Printf("[USER] %s (%s) [%s] %s\n", detail.Displayname, detail.Login, id, strings.Join(all[id], ","))
+			}
+			return nil
+		})
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func runNote(args []string) {
+	if len(args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	var noteID int
+	if args[0] == "edit" || args[0] == "remove" {
+		if len(args) < 3 {
+			printUsage()
+			os.Exit(2)
+		}
+		var err error
+		noteID, err = strconv.Atoi(args[2])
+		if err != nil {
+			log.Fatalf("runNote: invalid note ID %s", args[2])
+		}
+	}
+
+	switch {
+	case args[0] == "add" && len(args) >= 3:
+		uid := resolveUser(args[1])
+		var note tracker.Note
+		err := store.Update(func(tx storage.Tx) error {
+			var err error
+			note, err = tracker.AddNote(tx, uid, strings.Join(args[2:], " "), cliAuthor())
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Added note %d to %s [%s]\n", note.ID, args[1], uid)
+	case args[0] == "edit" && len(args) >= 4:
+		var found bool
+		err := store.Update(func(tx storage.Tx) error {
+			var err error
+			_, found, err = tracker.EditNote(tx, tracker.ResolveUserID(tx, args[1]), noteID, strings.Join(args[3:], " "), cliAuthor())
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !found {
+			fmt.Printf("[SYS] %s has no note %d\n", args[1], noteID)
+			os.Exit(1)
+		}
+		fmt.Printf("[SYS] Changed note %d of %s\n", noteID, args[1])
+	case args[0] == "remove" && len(args) == 3:
+		var found bool
+		err := store.Update(func(tx storage.Tx) error {
+			var err error
+			found, err = tracker.DeleteNote(tx, tracker.ResolveUserID(tx, args[1]), noteID)
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !found {
+			fmt.Printf("[SYS] %s has no note %d\n", args[1], noteID)
+			os.Exit(1)
+		}
+		fmt.Printf("[SYS] Removed note %d of %s\n", noteID, args[1])
+	case args[0] == "list" && len(args) == 2:
+		var notes tracker.UserNotes
+		store.View(func(tx storage.Tx) error {
+			notes = tracker.GetNotes(tx, tracker.ResolveUserID(tx, args[1]))
+			return nil
+		})
+		fmt.Printf("[USER] %s (%s) [%s]\n", notes.Displayname, notes.Login, notes.ID)
+		for _, l := range notes.Labels {
+			fmt.Printf("label %q by %s at %s\n", l.Name, l.Author, l.At)
+		}
+		for _, n := range notes.Notes {
+			edited := ""
+			if n.UpdatedAt != "" {
+				edited = fmt.Sprintf(", changed by %s at %s", n.UpdatedBy, n.UpdatedAt)
+			}
+			fmt.Printf("%d by %s at %s%s: %s\n", n.ID, n.Author, n.CreatedAt, edited, n.Text)
+		}
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func runLabel(args []string) {
+	switch {
+	case len(args) >= 3 && args[0] == "add":
+		uid := resolveUser(args[1])
+		err := store.Update(func(tx storage.Tx) error {
+			return tracker.AddLabel(tx, uid, strings.Join(args[2:], " "), cliAuthor())
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Labeled %s [%s] %q\n", args[1], uid, strings.Join(args[2:], " "))
+	case len(args) >= 3 && args[0] == "remove":
+		var removed bool
+		err := store.Update(func(tx storage.Tx) error {
+			var err error
+			removed, err = tracker.RemoveLabel(tx, tracker.ResolveUserID(tx, args[1]), strings.Join(args[2:], " "))
+			return err
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !removed {
+			fmt.Printf("[SYS] %s has no label %q\n", args[1], strings.Join(args[2:], " "))
+			os.Exit(1)
+		}
+		fmt.Printf("[SYS] Removed label %q of %s\n", strings.Join(args[2:], " "), args[1])
+	case len(args) >= 1 && args[0] == "list":
+		store.View(func(tx storage.Tx) error {
+			for _, n := range tracker.SearchNotes(tx, strings.Join(args[1:], " "), "") {
+				var labels []string
+				for _, l := range n.Labels {
+					labels = append(labels, l.Name)
+				}
+				fmt.Printf("[USER] %s (%s) [%s] %s\n", n.Displayname, n.Login, n.ID, strings.Join(labels, ", "))
+			}
+			return nil
+		})
+	default:
+		printUsage()
+		os.Exit(2)
+	}
+}
@@ -2,5 +2,4 @@ package main
 
 const defaultClientID = ""
 const defaultPort = "25001"
-const defaultDBName = "TUT.db"
 const defaultUpdateInterval = 60 // minutes
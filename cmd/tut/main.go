@@ -0,0 +1,234 @@
+// Command tut tracks followers and unfollowers of a Twitch channel and serves them over HTTP.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/devinjdawson/tut/api"
+	"github.com/devinjdawson/tut/notify"
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// store TUT keeps its data in, opened by main
+var store storage.Store
+
+func main() {
+	defaultStore := os.Getenv("TUT_STORE")
+	if defaultStore == "" {
+		defaultStore = "bolt"
+	}
+	storeKind := flag.String("store", defaultStore, "where TUT keeps its data, bolt or sqlite (env TUT_STORE)")
+	dbPath := flag.String("db", "", "path of the store, defaults to "+storage.DefaultDBName+" or "+storage.DefaultSQLiteName)
+	rebaseline := flag.Bool("rebaseline", false, "forget current followers and following, the next sync records a new baseline without reporting them")
+	flag.Usage = printUsage
+	flag.Parse()
+	if *dbPath == "" {
+		*dbPath = storage.DefaultPath(*storeKind)
+	}
+
+	if flag.NArg() > 0 && storelessCommands[flag.Arg(0)] {
+		runCommand(flag.Args())
+		return
+	}
+
+	var err error
+	store, err = storage.Open(*storeKind, *dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	if *rebaseline {
+		err = tracker.ResetBaseline(store)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("[SYS] Forgot current followers and following, the next sync records a new baseline\n")
+	}
+
+	if flag.NArg() > 0 {
+		runCommand(flag.Args())
+		return
+	}
+
+	fmt.Printf("[SYS] Welcome to TUT, Twitch Unfollow Tacker, v%s\n", tracker.Version)
+	conf, serverPort := initialize()
+	t := tracker.New(store, conf)
+	go func() {
+		log.Fatal(api.New(t).ListenAndServe(serverPort))
+	}()
+	go t.RunBackups()
+	mail := notify.NewEmail(store)
+	t.OnEvent(mail.Notify)
+	go mail.RunDigests()
+	hooks := notify.NewExec(store)
+	t.OnEvent(hooks.Notify)
+	chat := notify.NewChat(store)
+	t.OnEvent(chat.Notify)
+
+	fmt.Printf("[SYS] Starting... \n")
+	fmt.Printf("[SYS] Using %+v on port %s \n", conf, serverPort)
+	t.Run()
+}
+
+// initialize asks for the channel to track, keeping the answers in config
+func initialize() (tracker.Config, string) {
+	var clientID string
+	var oauth string
+	var username string
+	var userID string
+	var serverPort string
+	var updateInterval int
+	var err error
+
+	// Try to find clientID in config
+	store.Update(func(tx storage.Tx) error {
+		err := tracker.InitBaseline(tx)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		clientID, ok = tx.Config("clientID")
+		if !ok {
+			err = tx.SetConfig("clientID", defaultClientID)
+			if err != nil {
+				return err
+			}
+			clientID = defaultClientID
+			updateInterval = defaultUpdateInterval
+		} else {
+			oauth, _ = tx.Config("oauth")
+			interval, _ := tx.Config("updateInterval")
+			updateInterval, _ = strconv.Atoi(interval)
+		}
+		return nil
+	})
+
+	// Ask user whether to use saved clientID or new clientID
+	scanner := bufio.NewScanner(os.Stdin)
+	fmt.Printf("Simply Enter to use ClientID [%s] or Enter your ClientID: ", clientID)
+	scanner.Scan()
+	inputClinetID := scanner.Text()
+
+	// Update clientID if there is userinput
+	if len(inputClinetID) > 0 {
+		store.Update(func(tx storage.Tx) error {
+			return tx.SetConfig("clientID", inputClinetID)
+		})
+		clientID = inputClinetID
+	}
+
+	// Ask user whether to use saved OAuth or new OAuth
+	fmt.Printf("Simply Enter to use OAuth token [%s] or Enter your OAuth token [Optional]: ", oauth)
+	scanner.Scan()
+	inputOAuth := scanner.Text()
+
+	// Update clientID if there is userinput
+	if len(inputOAuth) > 0 {
+		inputOAuth = strings.Replace(inputOAuth, "oauth:", "", 1)
+		store.Update(func(tx storage.Tx) error {
+			return tx.SetConfig("oauth", inputOAuth)
+		})
+		oauth = inputOAuth
+	}
+
+	// Try to get userID from username
+	for len(username) == 0 {
+		store.View(func(tx storage.Tx) error {
+			username, _ = tx.Config("username")
+			userID, _ = tx.Config("userID")
+			return nil
+		})
+
+		// Ask user for username
+		if username == "" || userID == "" {
+			fmt.Printf("Enter Twitch Username to track: ")
+		} else {
+			fmt.Printf("Simply Enter to use Username [%s] or Enter your Username: ", username)
+		}
+		scanner.Scan()
+		inputUsername := scanner.Text()
+
+		// Update inputUsername if there is user input
+		if len(inputUsername) != 0 {
+			username = string([]byte(inputUsername))
+			store.Update(func(tx storage.Tx) error {
+				err = tx.SetConfig("username", username)
+				if err != nil {
+					return err
+				}
+				client := twitch.Client{ClientID: clientID, OAuth: oauth}
+			getUserID:
+				result, err := client.GetUserID(username)
+				if result.RateLimited() {
+					result.WaitForReset()
+					goto getUserID
+				}
+				if err != nil {
+					log.Fatal(err)
+				}
+				err = tx.SetConfig("userID", result.Response["id"])
+				if err != nil {
+					return err
+				}
+				userID = result.Response["id"]
+				return nil
+			})
+		}
+	}
+
+	// Ask user whether to use saved OAuth or new OAuth
+	fmt.Printf("Simply Enter to use update interval [%d] minutes or Enter your update interval: ", updateInterval)
+	scanner.Scan()
+	inputUpdateInterval := scanner.Text()
+
+	// Update clientID if there is userinput
+	if len(inputUpdateInterval) > 0 {
+		updateInterval, err = strconv.Atoi(inputUpdateInterval)
+		if err != nil {
+			log.Fatal("Please enter a valid number for update interval")
+		}
+		store.Update(func(tx storage.Tx) error {
+			return tx.SetConfig("updateInterval", inputUpdateInterval)
+		})
+	}
+
+	// Try to get serverPort
+	store.Update(func(tx storage.Tx) error {
+		port, ok := tx.Config("serverPort")
+		if ok {
+			serverPort = port
+		} else {
+			tx.SetConfig("serverPort", defaultPort)
+			serverPort = defaultPort
+		}
+		return nil
+	})
+
+	// Ask user whether to use saved server port or enter new server port
+	fmt.Printf("Simply Enter to use server port [%s] or Enter your server port: ", serverPort)
+	scanner.Scan()
+	inputServerPort := scanner.Text()
+
+	// Update clientID if there is userinput
+	if len(inputServerPort) > 0 {
+		_, isInt := strconv.Atoi(inputServerPort)
+		if isInt != nil {
+			log.Fatal("Please enter valid port")
+		}
+		store.Update(func(tx storage.Tx) error {
+			return tx.SetConfig("serverPort", inputServerPort)
+		})
+		serverPort = inputServerPort
+	}
+
+	return tracker.Config{ClientID: clientID, OAuth: oauth, Username: username, UserID: userID, UpdateInterval: updateInterval}, serverPort
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/devinjdawson/tut/api"
+)
+
+// TestClientUpToDate fails when a route changed without running go generate ./client
+func TestClientUpToDate(t *testing.T) {
+	data, err := json.Marshal(api.OpenAPISpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := ioutil.ReadFile("../../client/api_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, committed) {
+		t.Error("client/api_gen.go doesn't match the routes, run go generate ./client")
+	}
+}
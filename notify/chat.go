@@ -0,0 +1,360 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// maxChatMessage Twitch drops longer chat messages
+const maxChatMessage = 500
+
+// Chat thanks new followers, welcomes back refollowers and celebrates milestones in the Twitch chat of the channel
+type Chat struct {
+	store storage.Store
+	queue chan tracker.Event
+
+	mu        sync.Mutex
+	announced map[string]time.Time // user ID to when chat last named them
+}
+
+// Announcement what chatFollowMessage, chatRefollowMessage and chatMilestoneMessage render, Names lists the
+// display names for chat, Milestone and Followers are set for milestones
+type Announcement struct {
+	Channel   string
+	Type      string
+	Users     []User
+	Names     string
+	Milestone string
+	Followers string
+}
+
+// NewChat creates the chat announcements of store and starts posting them in the background
+func NewChat(store storage.Store) *Chat {
+	c := &Chat{store: store, queue: make(chan tracker.Event, 100), announced: make(map[string]time.Time)}
+	go c.announceQueued()
+	return c
+}
+
+// Notify queues follows when chatFollow is on, refollows when chatRefollow is on and reached milestones when
+// chatMilestone is on, for use with tracker.OnEvent
+func (c *Chat) Notify(e tracker.Event) {
+	var announce bool
+	c.store.View(func(tx storage.Tx) error {
+		announce = chatEnabled(tx, e)
+		return nil
+	})
+	if !announce {
+		return
+	}
+	select {
+	case c.queue <- e:
+	default:
+		fmt.Printf("[SYS] Too many chat announcements queued, dropped %s of %s\n", e.Type, e.UserID)
+	}
+}
+
+// chatEnabled tells whether e is announced
+func chatEnabled(tx storage.Tx, e tracker.Event) bool {
+	switch e.Type {
+	case storage.EventFollow:
+		return storage.BoolSetting(tx, "chatFollow")
+	case storage.EventRefollow:
+		return storage.BoolSetting(tx, "chatRefollow")
+	case storage.EventMilestone:
+		return storage.BoolSetting(tx, "chatMilestone") && e.Details["direction"] == "reached"
+	}
+	return false
+}
+
+// announceQueued collects events for chatBatch seconds, at least until chatCooldown passed since the last
+// announcement, and posts one message per event type
+func (c *Chat) announceQueued() {
+	var last time.Time
+	for e := range c.queue {
+		var batch, cooldown int
+		c.store.View(func(tx storage.Tx) error {
+			batch = storage.IntSetting(tx, "chatBatch")
+			cooldown = storage.IntSetting(tx, "chatCooldown")
+			return nil
+		})
+		wait := time.Duration(batch) * time.Second
+		if untilCooldown := time.Until(last.Add(time.Duration(cooldown) * time.Second)); untilCooldown > wait {
+			wait = untilCooldown
+		}
+
+		events := []tracker.Event{e}
+		timer := time.NewTimer(wait)
+	collect:
+		for {
+			select {
+			case e := <-c.queue:
+				events = append(events, e)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		messages, named, err := c.messages(events)
+		if err == nil && len(messages) > 0 {
+			err = c.Say(messages...)
+			// A failed post didn't reach chat, the cooldowns start with the next one that does
+			if err == nil {
+				last = time.Now()
+				c.markAnnounced(named, last)
+			}
+		}
+		if err != nil {
+			fmt.Printf("[SYS] Chat announcement of %d events failed: %v\n", len(events), err)
+		}
+	}
+}
+
+// messages renders one message per milestone of events and one per other event type, leaving out users named
+// within chatUserCooldown hours, and returns the IDs of the users it names
+func (c *Chat) messages(events []tracker.Event) ([]string, []string, error) {
+	var channel string
+	var userCooldown int
+	var milestones []tracker.Event
+	texts := make(map[string]string)
+	users := make(map[string][]User)
+	c.store.View(func(tx storage.Tx) error {
+		channel, _ = tx.Config("username")
+		userCooldown = storage.IntSetting(tx, "chatUserCooldown")
+		texts[storage.EventFollow] = storage.Setting(tx, "chatFollowMessage")
+		texts[storage.EventRefollow] = storage.Setting(tx, "chatRefollowMessage")
+		texts[storage.EventMilestone] = storage.Setting(tx, "chatMilestoneMessage")
+		for _, e := range events {
+			// The setting may have been turned off while the event waited
+			if !chatEnabled(tx, e) {
+				continue
+			}
+			if e.Type == storage.EventMilestone {
+				milestones = append(milestones, e)
+			} else {
+				users[e.Type] = append(users[e.Type], templateUser(tx, e))
+			}
+		}
+		return nil
+	})
+
+	var messages []string
+	for _, e := range milestones {
+		message, err := renderAnnouncement(texts[e.Type], Announcement{Channel: channel, Type: e.Type, Milestone: e.Details["milestone"], Followers: e.Details["followers"]})
+		if err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var namedIDs []string
+	seen := make(map[string]bool)
+	for _, eventType := range []string{storage.EventFollow, storage.EventRefollow} {
+		var named []User
+		for _, u := range users[eventType] {
+			if at, ok := c.announced[u.ID]; (ok && time.Since(at) < time.Duration(userCooldown)*time.Hour) || seen[u.ID] {
+				continue
+			}
+			seen[u.ID] = true
+			named = append(named, u)
+			namedIDs = append(namedIDs, u.ID)
+		}
+		if len(named) == 0 {
+			continue
+		}
+		message, err := renderAnnouncement(texts[eventType], Announcement{Channel: channel, Type: eventType, Users: named})
+		if err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, namedIDs, nil
+}
+
+// markAnnounced remembers when chat named users, to leave them out for chatUserCooldown hours
+func (c *Chat) markAnnounced(uids []string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, uid := range uids {
+		c.announced[uid] = at
+	}
+}
+
+// Preview renders the follow and refollow messages of one and of three made-up users, and a milestone message
+func (c *Chat) Preview() ([]string, error) {
+	var channel string
+	texts := make(map[string]string)
+	c.store.View(func(tx storage.Tx) error {
+		channel, _ = tx.Config("username")
+		texts[storage.EventFollow] = storage.Setting(tx, "chatFollowMessage")
+		texts[storage.EventRefollow] = storage.Setting(tx, "chatRefollowMessage")
+		texts[storage.EventMilestone] = storage.Setting(tx, "chatMilestoneMessage")
+		return nil
+	})
+	users := []User{{ID: "1", Login: "viewer_one", Displayname: "Viewer_One"}, {ID: "2", Login: "viewer_two", Displayname: "Viewer_Two"}, {ID: "3", Login: "viewer_three", Displayname: "Viewer_Three"}}
+	var messages []string
+	for _, eventType := range []string{storage.EventFollow, storage.EventRefollow} {
+		for _, n := range []int{1, 3} {
+			message, err := renderAnnouncement(texts[eventType], Announcement{Channel: channel, Type: eventType, Users: users[:n]})
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+	}
+	message, err := renderAnnouncement(texts[storage.EventMilestone], Announcement{Channel: channel, Type: storage.EventMilestone, Milestone: "1000", Followers: "1000"})
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, message), nil
+}
+
+// renderAnnouncement renders text with as many names as fit into a chat message, the others as "and N more"
+func renderAnnouncement(text string, a Announcement) (string, error) {
+	tmpl, err := template.New("chat").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	for shown := len(a.Users); ; shown-- {
+		a.Names = chatNames(a.Users, shown)
+		var b bytes.Buffer
+		err = tmpl.Execute(&b, a)
+		if err != nil {
+			return "", err
+		}
+		// A line break would end the IRC command
+		message := strings.Join(strings.Fields(b.String()), " ")
+		if utf8.RuneCountInString(message) <= maxChatMessage {
+			return message, nil
+		}
+		if shown <= 1 {
+			return string([]rune(message)[:maxChatMessage]), nil
+		}
+	}
+}
+
+// chatNames lists the first shown display names, "a, b and c" or "a, b and 3 more"
+func chatNames(users []User, shown int) string {
+	var names []string
+	for _, u := range users[:shown] {
+		names = append(names, u.Displayname)
+	}
+	if more := len(users) - shown; more > 0 {
+		return strings.Join(names, ", ") + fmt.Sprintf(" and %d more", more)
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// Say posts messages in the chat of the channel as chatUser, connecting to chatServer for just these messages
+func (c *Chat) Say(messages ...string) error {
+	var server, login, token, channel string
+	var useTLS bool
+	c.store.View(func(tx storage.Tx) error {
+		server = storage.Setting(tx, "chatServer")
+		useTLS = storage.BoolSetting(tx, "chatTLS")
+		channel, _ = tx.Config("username")
+		login = storage.Setting(tx, "chatUser")
+		if login == "" {
+			login = channel
+		}
+		token = storage.Setting(tx, "chatOAuth")
+		if token == "" {
+			token, _ = tx.Config("oauth")
+		}
+		return nil
+	})
+	if token == "" {
+		return errors.New("Say: no OAuth token, set chatOAuth or the OAuth token of TUT")
+	}
+	if channel == "" {
+		return errors.New("Say: no channel, start TUT once to set the username")
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", server, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", server)
+	}
+	if err != nil {
+		return fmt.Errorf("Say: %s: %v", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	irc := &ircConn{conn: conn, r: bufio.NewReader(conn)}
+	irc.send("PASS oauth:" + strings.TrimPrefix(token, "oauth:"))
+	irc.send("NICK " + strings.ToLower(login))
+	err = irc.waitFor("001")
+	if err != nil {
+		return err
+	}
+	irc.send("JOIN #" + strings.ToLower(channel))
+	err = irc.waitFor("366")
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		irc.send("PRIVMSG #" + strings.ToLower(channel) + " :" + message)
+	}
+	// Twitch answers in order, so the echo of PART means the messages went through
+	irc.send("PART #" + strings.ToLower(channel))
+	return irc.waitFor("PART")
+}
+
+// ircConn a connection to Twitch IRC, err keeps the first write error
+type ircConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	err  error
+}
+
+func (c *ircConn) send(line string) {
+	if c.err == nil {
+		_, c.err = fmt.Fprintf(c.conn, "%s\r\n", line)
+	}
+}
+
+// waitFor reads until a reply with command, answering pings and failing on a rejected login
+func (c *ircConn) waitFor(command string) error {
+	for c.err == nil {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("waitFor: %s: %v", command, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "PING" {
+			c.send("PONG" + strings.TrimPrefix(line, "PING"))
+			continue
+		}
+		if len(fields) > 1 && fields[1] == command {
+			return nil
+		}
+		if len(fields) > 1 && fields[1] == "NOTICE" {
+			if i := strings.Index(line, " :"); i >= 0 {
+				line = line[i+2:]
+			}
+			return fmt.Errorf("waitFor: %s: Twitch said %s", command, line)
+		}
+	}
+	return c.err
+}
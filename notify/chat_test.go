@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// fakeIRC a local stand-in for Twitch IRC that passes on every line clients send and fails the first
+// rejectLogins logins like Twitch does for a bad token
+type fakeIRC struct {
+	addr  string
+	lines chan string
+
+	mu           sync.Mutex
+	rejectLogins int
+}
+
+func startFakeIRC(t *testing.T, rejectLogins int) *fakeIRC {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &fakeIRC{addr: l.Addr().String(), lines: make(chan string, 100), rejectLogins: rejectLogins}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve answers one client until it disconnects
+func (s *fakeIRC) serve(conn net.Conn) {
+	defer conn.Close()
+	var nick string
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		s.lines <- line
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "NICK":
+			nick = fields[1]
+			s.mu.Lock()
+			reject := s.rejectLogins > 0
+			s.rejectLogins--
+			s.mu.Unlock()
+			if reject {
+				fmt.Fprintf(conn, ":tmi.twitch.tv NOTICE * :Login authentication failed\r\n")
+				return
+			}
+			fmt.Fprintf(conn, ":tmi.twitch.tv 001 %s :Welcome, GLHF!\r\n", nick)
+			fmt.Fprintf(conn, ":tmi.twitch.tv 376 %s :>\r\n", nick)
+		case "JOIN":
+			// A ping in between must be answered without ending the wait for the join
+			fmt.Fprintf(conn, "PING :tmi.twitch.tv\r\n")
+			fmt.Fprintf(conn, ":%s!%s@%s.tmi.twitch.tv JOIN %s\r\n", nick, nick, nick, fields[1])
+			fmt.Fprintf(conn, ":%s.tmi.twitch.tv 366 %s %s :End of /NAMES list\r\n", nick, nick, fields[1])
+		case "PART":
+			fmt.Fprintf(conn, ":%s!%s@%s.tmi.twitch.tv PART %s\r\n", nick, nick, nick, fields[1])
+		}
+	}
+}
+
+// next waits for the next line a client sent
+func (s *fakeIRC) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case line := <-s.lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake IRC server got no line within 5s")
+		return ""
+	}
+}
+
+// testChat opens a store and a Chat posting to server, without a batch or cooldown unless settings say otherwise
+func testChat(t *testing.T, server *fakeIRC, settings map[string]string) *Chat {
+	t.Helper()
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	config := map[string]string{"username": "SomeChannel", "oauth": "oauth:token", "chatServer": server.addr, "chatTLS": "false", "chatBatch": "0", "chatCooldown": "0"}
+	for k, v := range settings {
+		config[k] = v
+	}
+	err = store.Update(func(tx storage.Tx) error {
+		for k, v := range config {
+			if err := tx.SetConfig(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChat(store)
+}
+
+func TestSay(t *testing.T) {
+	server := startFakeIRC(t, 0)
+	c := testChat(t, server, nil)
+
+	err := c.Say("Thank you for the follow, Alice!", "Welcome back, Bob!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"PASS oauth:token",
+		"NICK somechannel",
+		"JOIN #somechannel",
+		"PONG :tmi.twitch.tv",
+		"PRIVMSG #somechannel :Thank you for the follow, Alice!",
+		"PRIVMSG #somechannel :Welcome back, Bob!",
+		"PART #somechannel",
+	} {
+		if got := server.next(t); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSayLoginFailure(t *testing.T) {
+	server := startFakeIRC(t, 1)
+	c := testChat(t, server, map[string]string{"chatUser": "SomeBot", "chatOAuth": "bottoken"})
+
+	err := c.Say("never posted")
+	if err == nil || !strings.Contains(err.Error(), "Login authentication failed") {
+		t.Fatalf("got error %v, want the login failure", err)
+	}
+	for _, want := range []string{"PASS oauth:bottoken", "NICK somebot"} {
+		if got := server.next(t); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	select {
+	case line := <-server.lines:
+		t.Errorf("sent %q after the login failed", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRenderAnnouncement(t *testing.T) {
+	users := func(n int, name string) []User {
+		var users []User
+		for i := 1; i <= n; i++ {
+			users = append(users, User{ID: fmt.Sprint(i), Displayname: fmt.Sprintf("%s%02d", name, i)})
+		}
+		return users
+	}
+
+	for _, test := range []struct {
+		users []User
+		want  string
+	}{
+		{users(1, "Viewer"), "Thanks Viewer01!"},
+		{users(2, "Viewer"), "Thanks Viewer01 and Viewer02!"},
+		{users(3, "Viewer"), "Thanks Viewer01, Viewer02 and Viewer03!"},
+	} {
+		got, err := renderAnnouncement("Thanks {{.Names}}!", Announcement{Users: test.users})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+
+	// Names that don't fit are counted instead
+	many := users(60, strings.Repeat("x", 20))
+	got, err := renderAnnouncement("Thanks {{.Names}}!", Announcement{Users: many})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var more int
+	if n, _ := fmt.Sscanf(got[strings.LastIndex(got, " and ")+1:], "and %d more!", &more); n != 1 {
+		t.Fatalf("%q doesn't end with the number of names left out", got)
+	}
+	shown := strings.Count(got, strings.Repeat("x", 20))
+	if shown+more != len(many) || utf8.RuneCountInString(got) > maxChatMessage {
+		t.Errorf("%d names shown and %d more in %d characters, want %d names in at most %d", shown, more, utf8.RuneCountInString(got), len(many), maxChatMessage)
+	}
+	if !strings.Contains(got, chatNames(many, shown)) {
+		t.Errorf("%q doesn't name the first %d users", got, shown)
+	}
+
+	// A single name too long for chat is cut, line breaks would end the IRC command
+	got, err = renderAnnouncement("Thanks\n{{.Names}}!", Announcement{Users: []User{{ID: "1", Displayname: strings.Repeat("é", 600)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utf8.RuneCountInString(got) != maxChatMessage || !strings.HasPrefix(got, "Thanks éé") {
+		t.Errorf("got %d characters %.20q..., want %d starting with the text", utf8.RuneCountInString(got), got, maxChatMessage)
+	}
+}
+
+func TestFailedPostStartsNoCooldown(t *testing.T) {
+	server := startFakeIRC(t, 1)
+	c := testChat(t, server, map[string]string{"chatFollow": "true", "chatCooldown": "3600", "chatUserCooldown": "24"})
+
+	c.Notify(tracker.Event{Type: storage.EventFollow, UserID: "1", Displayname: "Alice"})
+	for server.next(t) != "NICK somechannel" {
+	}
+
+	// The first post failed, so the next follow is thanked right away instead of an hour later, and Alice, who
+	// was never thanked, is named again
+	c.Notify(tracker.Event{Type: storage.EventFollow, UserID: "1", Displayname: "Alice"})
+	wantPrivmsg(t, server, "PRIVMSG #somechannel :Thank you for the follow, Alice! <3")
+
+	// Once thanked, she isn't named again within chatUserCooldown
+	c.store.Update(func(tx storage.Tx) error { return tx.SetConfig("chatCooldown", "0") })
+	c.Notify(tracker.Event{Type: storage.EventFollow, UserID: "1", Displayname: "Alice"})
+	c.Notify(tracker.Event{Type: storage.EventFollow, UserID: "2", Displayname: "Bob"})
+	wantPrivmsg(t, server, "PRIVMSG #somechannel :Thank you for the follow, Bob! <3")
+}
+
+// wantPrivmsg waits for the next message posted in chat
+func wantPrivmsg(t *testing.T, server *fakeIRC, want string) {
+	t.Helper()
+	for {
+		line := server.next(t)
+		if strings.HasPrefix(line, "PRIVMSG") {
+			if line != want {
+				t.Errorf("got %q, want %q", line, want)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// Config keys remembering where the last digest ended
+const (
+	digestAtKey    = "emailDigestAt"
+	digestEventKey = "emailDigestEvent"
+)
+
+// Digest what digest.tmpl renders, everything between Since and Until
+type Digest struct {
+	Channel     string
+	Period      string // daily, weekly, or empty for a digest sent by hand
+	Since       string
+	Until       string
+	Followers   []User // new followers
+	Unfollowers []User // genuine unfollows
+	Removed     []User // unfollowers banned or whose accounts were deleted or suspended
+	Refollowers []User
+	Net         int // follows and refollows minus unfollows, removed ones included
+	Total       int // followers now
+}
+
+const defaultDigestTemplate = `{{define "subject"}}TUT {{with .Period}}{{.}} {{end}}digest of {{.Channel}}: {{printf "%+d" .Net}} followers{{end}}
+{{- define "user"}}  {{.Displayname}}{{with .Login}} ({{.}}){{end}} [{{.ID}}] {{.At}}{{with .Roles}} {{.}}{{end}}{{with .Reason}} {{.}}{{end}}
+{{end}}
+{{- define "body"}}{{.Channel}} from {{.Since}} to {{.Until}}
+
+Net change: {{printf "%+d" .Net}}, {{.Total}} followers now
+
+New followers: {{len .Followers}}
+{{range .Followers}}{{template "user" .}}{{end}}
+Unfollowers: {{len .Unfollowers}}
+{{range .Unfollowers}}{{template "user" .}}{{end}}
+{{- with .Removed}}
+Banned or closed accounts: {{len .}}
+{{range .}}{{template "user" .}}{{end}}{{end}}
+Refollowers: {{len .Refollowers}}
+{{range .Refollowers}}{{template "user" .}}{{end}}
+-- TUT, Twitch Unfollow Tracker
+{{end}}`
+
+// RunDigests sends a digest every day, or every emailDigestWeekday, at emailDigestTime in scheduleTimezone
+// while emailDigest is daily or weekly. RunDigests doesn't return.
+func (m *Email) RunDigests() {
+	for {
+		var period, clock, weekday, since string
+		var loc *time.Location
+		m.store.View(func(tx storage.Tx) error {
+			period = storage.Setting(tx, "emailDigest")
+			clock = storage.Setting(tx, "emailDigestTime")
+			weekday = storage.Setting(tx, "emailDigestWeekday")
+			since, _ = tx.Config(digestAtKey)
+			var err error
+			loc, err = time.LoadLocation(storage.Setting(tx, "scheduleTimezone"))
+			if err != nil {
+				loc = time.Local
+			}
+			return nil
+		})
+
+		// The first digest covers what happens from now on
+		if since == "" {
+			err := m.startDigest()
+			if err != nil {
+				fmt.Printf("[SYS] Cannot start email digests: %v\n", err)
+			}
+			since = time.Now().UTC().Format(time.RFC3339)
+		}
+
+		wait := 5 * time.Minute
+		last, err := time.Parse(time.RFC3339, since)
+		if period == "daily" || period == "weekly" {
+			next, nextErr := nextDigest(last, period, clock, weekday, loc)
+			if err == nil {
+				err = nextErr
+			}
+			if err != nil {
+				fmt.Printf("[SYS] Invalid email digest settings: %v\n", err)
+			} else if !time.Now().Before(next) {
+				err = m.SendDigest(period)
+				if err != nil {
+					fmt.Printf("[SYS] Email digest failed: %v\n", err)
+				} else {
+					fmt.Printf("[SYS] Sent %s email digest\n", period)
+				}
+			} else if next.Sub(time.Now()) < wait {
+				wait = next.Sub(time.Now())
+			}
+		}
+
+		// Settings may change at any time, check them at least every few minutes
+		time.Sleep(wait)
+	}
+}
+
+// nextDigest finds the first digest time after last
+func nextDigest(last time.Time, period string, clock string, weekday string, loc *time.Location) (time.Time, error) {
+	at, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("nextDigest: emailDigestTime %q is not HH:MM", clock)
+	}
+	day := -1
+	if period == "weekly" {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(d.String(), strings.TrimSpace(weekday)) {
+				day = int(d)
+			}
+		}
+		if day < 0 {
+			return time.Time{}, fmt.Errorf("nextDigest: emailDigestWeekday %q is not a day of the week", weekday)
+		}
+	}
+
+	l := last.In(loc)
+	next := time.Date(l.Year(), l.Month(), l.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	for !next.After(last) || (day >= 0 && int(next.Weekday()) != day) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, at.Hour(), at.Minute(), 0, 0, loc)
+	}
+	return next, nil
+}
+
+// startDigest starts a digest period now, without the events recorded before
+func (m *Email) startDigest() error {
+	return m.store.Update(func(tx storage.Tx) error {
+		var lastID uint64
+		tx.ForEachEvent(func(e tracker.Event) error {
+			lastID = e.ID
+			return nil
+		})
+		return markDigest(tx, lastID, time.Now().UTC())
+	})
+}
+
+// markDigest remembers the last event and the time a digest covered
+func markDigest(tx storage.Tx, lastID uint64, until time.Time) error {
+	err := tx.SetConfig(digestEventKey, strconv.FormatUint(lastID, 10))
+	if err != nil {
+		return err
+	}
+	return tx.SetConfig(digestAtKey, until.Format(time.RFC3339))
+}
+
+// buildDigest collects the follows, unfollows and refollows since the last digest and the ID of the last event it saw
+func (m *Email) buildDigest(period string) (Digest, uint64, error) {
+	var d Digest
+	var lastID uint64
+	err := m.store.View(func(tx storage.Tx) error {
+		d, lastID = digestEvents(tx, period)
+		return nil
+	})
+	return d, lastID, err
+}
+
+// digestEvents collects the events after the last digest
+func digestEvents(tx storage.Tx, period string) (Digest, uint64) {
+	d := Digest{Period: period, Until: time.Now().UTC().Format(time.RFC3339)}
+	d.Channel, _ = tx.Config("username")
+	d.Since, _ = tx.Config(digestAtKey)
+	last, _ := tx.Config(digestEventKey)
+	lastID, _ := strconv.ParseUint(last, 10, 64)
+
+	tx.ForEachEvent(func(e tracker.Event) error {
+		if e.ID <= lastID {
+			return nil
+		}
+		lastID = e.ID
+		// Without an earlier digest, the digest covers the whole history
+		if d.Since == "" {
+			d.Since = e.At
+		}
+		// Events are recorded before tags are applied, ignored users stay out of digests too
+		if tracker.HasTag(tracker.GetTags(tx, e.UserID), tracker.TagIgnore) {
+			return nil
+		}
+		switch e.Type {
+		case storage.EventFollow:
+			d.Followers = append(d.Followers, templateUser(tx, e))
+			d.Net++
+		case storage.EventRefollow:
+			d.Refollowers = append(d.Refollowers, templateUser(tx, e))
+			d.Net++
+		case storage.EventUnfollow:
+			if e.Details["reason"] != "" {
+				d.Removed = append(d.Removed, templateUser(tx, e))
+			} else {
+				d.Unfollowers = append(d.Unfollowers, templateUser(tx, e))
+			}
+			d.Net--
+		}
+		return nil
+	})
+	d.Total = tx.CountRelations(storage.ListFollowers)
+	if d.Since == "" {
+		d.Since = d.Until
+	}
+	return d, lastID
+}
+
+// RenderDigest renders the digest since the last one without sending it
+func (m *Email) RenderDigest(period string) (string, string, error) {
+	d, _, err := m.buildDigest(period)
+	if err != nil {
+		return "", "", err
+	}
+	text, err := m.template("digest.tmpl", defaultDigestTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	return execute("digest.tmpl", text, d)
+}
+
+// SendDigest mails the digest since the last one and starts the next period
+func (m *Email) SendDigest(period string) error {
+	d, lastID, err := m.buildDigest(period)
+	if err != nil {
+		return err
+	}
+	err = m.render("digest.tmpl", defaultDigestTemplate, d)
+	if err != nil {
+		return err
+	}
+	until, _ := time.Parse(time.RFC3339, d.Until)
+	return m.store.Update(func(tx storage.Tx) error {
+		return markDigest(tx, lastID, until)
+	})
+}
@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// Email sends events and digests by SMTP, configured by the smtp* and email* settings
+type Email struct {
+	store storage.Store
+	queue chan tracker.Event
+}
+
+// NewEmail creates the email notifier of store and starts sending instant emails in the background
+func NewEmail(store storage.Store) *Email {
+	m := &Email{store: store, queue: make(chan tracker.Event, 100)}
+	go m.sendQueued()
+	return m
+}
+
+const defaultInstantTemplate = `{{define "subject"}}[{{.Channel}}] {{if eq .Event.Type "milestone"}}{{if eq .Event.Details.direction "reached"}}Reached{{else}}Dropped below{{end}} {{.Event.Details.milestone}} followers{{else}}{{.Event.Type}}: {{.User.Displayname}}{{end}}{{end}}
+{{- define "body"}}{{if eq .Event.Type "milestone"}}{{.Channel}} {{if eq .Event.Details.direction "reached"}}reached{{else}}dropped below{{end}} {{.Event.Details.milestone}} followers at {{.Event.At}}, now {{.Event.Details.followers}} followers.
+{{- else}}{{.User.Displayname}}{{with .User.Login}} ({{.}}){{end}} [{{.User.ID}}]
+
+{{.Event.Type}} at {{.User.At}}
+{{- with .User.FollowedAt}}, followed at {{.}}{{end}}
+{{- with .Event.Details.reason}}
+reason: {{.}}{{end}}
+{{- with .Event.Details.status}}
+account: {{.}}, missing since {{$.Event.Details.missingSince}}{{end}}
+{{- with .Event.Details.roles}}
+roles: {{.}}{{end}}
+{{- with .Event.Details.tags}}
+tags: {{.}}{{end}}
+{{- end}}
+
+-- TUT, Twitch Unfollow Tracker
+{{end}}`
+
+// Notify queues an email of e when its type is listed in emailInstant or its user is watched, for use with
+// tracker.OnEvent
+func (m *Email) Notify(e tracker.Event) {
+	var instant bool
+	m.store.View(func(tx storage.Tx) error {
+		instant = storage.Setting(tx, "smtpHost") != "" && (contains(splitList(storage.Setting(tx, "emailInstant")), e.Type) || tracker.Watched(e))
+		return nil
+	})
+	if !instant {
+		return
+	}
+	select {
+	case m.queue <- e:
+	default:
+		fmt.Printf("[SYS] Too many emails queued, dropped %s of %s\n", e.Type, e.UserID)
+	}
+}
+
+// sendQueued sends instant emails one at a time
+func (m *Email) sendQueued() {
+	for e := range m.queue {
+		data := notice(m.store, e)
+		err := m.render("instant.tmpl", defaultInstantTemplate, data)
+		if err != nil {
+			fmt.Printf("[SYS] Email of %s %s failed: %v\n", e.Type, e.UserID, err)
+		}
+	}
+}
+
+// SendTest sends a short email to check the SMTP settings
+func (m *Email) SendTest() error {
+	return m.send("TUT test email", "SMTP settings of TUT work.\n")
+}
+
+// template reads template name from emailTemplateDir, the built-in template when there is none
+func (m *Email) template(name string, builtin string) (string, error) {
+	var dir string
+	m.store.View(func(tx storage.Tx) error {
+		dir = storage.Setting(tx, "emailTemplateDir")
+		return nil
+	})
+	if dir == "" {
+		return builtin, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return builtin, nil
+	}
+	return string(b), err
+}
+
+// render executes the "subject" and "body" templates of name and sends the result
+func (m *Email) render(name string, builtin string, data interface{}) error {
+	text, err := m.template(name, builtin)
+	if err != nil {
+		return err
+	}
+	subject, body, err := execute(name, text, data)
+	if err != nil {
+		return err
+	}
+	return m.send(subject, body)
+}
+
+// execute renders subject and body of a template
+func execute(name string, text string, data interface{}) (string, string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", "", err
+	}
+	var subject, body bytes.Buffer
+	err = tmpl.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return "", "", err
+	}
+	err = tmpl.ExecuteTemplate(&body, "body", data)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject.String()), body.String(), nil
+}
+
+// send mails a plain text message to emailTo
+func (m *Email) send(subject string, body string) error {
+	var host, port, user, password, from string
+	var to []string
+	m.store.View(func(tx storage.Tx) error {
+		host = storage.Setting(tx, "smtpHost")
+		port = storage.Setting(tx, "smtpPort")
+		user = storage.Setting(tx, "smtpUser")
+		password = storage.Setting(tx, "smtpPassword")
+		from = storage.Setting(tx, "emailFrom")
+		to = splitList(storage.Setting(tx, "emailTo"))
+		return nil
+	})
+	if host == "" || len(to) == 0 {
+		return errors.New("send: set smtpHost and emailTo first")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+	return smtp.SendMail(net.JoinHostPort(host, port), auth, from, to, msg.Bytes())
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// smtpSink accepts mail on a local port and passes every message it receives on, or rejects all of them
+// after DATA when reject is set
+func smtpSink(t *testing.T, reject bool) (string, <-chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, reject, messages)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return port, messages
+}
+
+func serveSMTP(conn net.Conn, reject bool, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 sink")
+		case command == "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				msg.WriteString(line)
+			}
+			if reject {
+				reply("554 rejected")
+				continue
+			}
+			messages <- msg.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// testEmail opens a store and an Email sending to an SMTP sink on port
+func testEmail(t *testing.T, port string) (*Email, storage.Store) {
+	t.Helper()
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	err = store.Update(func(tx storage.Tx) error {
+		for k, v := range map[string]string{"username": "somechannel", "smtpHost": "127.0.0.1", "smtpPort": port, "emailTo": "me@example.com"} {
+			if err := tx.SetConfig(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEmail(store), store
+}
+
+func TestSendTest(t *testing.T) {
+	port, messages := smtpSink(t, false)
+	m, _ := testEmail(t, port)
+
+	err := m.SendTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := <-messages
+	for _, want := range []string{"To: me@example.com\r\n", "Subject: TUT test email\r\n", "SMTP settings of TUT work.\r\n"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("test email lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	port, messages := smtpSink(t, false)
+	m, store := testEmail(t, port)
+
+	var lastID uint64
+	err := store.Update(func(tx storage.Tx) error {
+		for _, e := range []tracker.Event{
+			{Type: storage.EventFollow, UserID: "1", Login: "alice", Displayname: "Alice", At: "2024-05-01T10:00:00Z"},
+			{Type: storage.EventUnfollow, UserID: "2", Login: "bob", Displayname: "Bob", At: "2024-05-01T11:00:00Z"},
+			{Type: storage.EventUnfollow, UserID: "3", Login: "carol", Displayname: "Carol", At: "2024-05-01T12:00:00Z", Details: map[string]string{"reason": tracker.ReasonBanned}},
+			{Type: storage.EventRefollow, UserID: "4", Login: "dave", Displayname: "Dave", At: "2024-05-01T13:00:00Z"},
+		} {
+			var err error
+			lastID, err = tx.AppendEvent(e)
+			if err != nil {
+				return err
+			}
+		}
+		for _, uid := range []string{"1", "4"} {
+			if err := tx.PutRelation(storage.ListFollowers, uid, "2024-05-01T10:00:00Z"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, body, err := m.RenderDigest("daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "TUT daily digest of somechannel: +0 followers"; subject != want {
+		t.Errorf("subject %q, want %q", subject, want)
+	}
+	for _, want := range []string{
+		"somechannel from 2024-05-01T10:00:00Z to ",
+		"Net change: +0, 2 followers now\n",
+		"New followers: 1\n  Alice (alice) [1] 2024-05-01T10:00:00Z\n",
+		"Unfollowers: 1\n  Bob (bob) [2] 2024-05-01T11:00:00Z\n",
+		"Banned or closed accounts: 1\n  Carol (carol) [3] 2024-05-01T12:00:00Z banned\n",
+		"Refollowers: 1\n  Dave (dave) [4] 2024-05-01T13:00:00Z\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest lacks %q:\n%s", want, body)
+		}
+	}
+
+	cursor := func() (string, string) {
+		var event, at string
+		store.View(func(tx storage.Tx) error {
+			event, _ = tx.Config(digestEventKey)
+			at, _ = tx.Config(digestAtKey)
+			return nil
+		})
+		return event, at
+	}
+
+	// A digest the SMTP server refused is sent again next time
+	rejectPort, _ := smtpSink(t, true)
+	store.Update(func(tx storage.Tx) error { return tx.SetConfig("smtpPort", rejectPort) })
+	if err := m.SendDigest("daily"); err == nil {
+		t.Fatal("SendDigest to a rejecting server succeeded")
+	}
+	if event, at := cursor(); event != "" || at != "" {
+		t.Errorf("failed digest moved the cursor to event %q at %q", event, at)
+	}
+
+	store.Update(func(tx storage.Tx) error { return tx.SetConfig("smtpPort", port) })
+	if err := m.SendDigest("daily"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := <-messages; !strings.Contains(msg, "New followers: 1\r\n") {
+		t.Errorf("sent digest lacks the new follower:\n%s", msg)
+	}
+	if event, at := cursor(); event != strconv.FormatUint(lastID, 10) || at == "" {
+		t.Errorf("sent digest left the cursor at event %q at %q, want event %d", event, at, lastID)
+	}
+
+	_, body, err = m.RenderDigest("daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "New followers: 0\n") || !strings.Contains(body, "Unfollowers: 0\n") {
+		t.Errorf("digest after sending still has the old events:\n%s", body)
+	}
+}
@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// hookWaitDelay how long Run waits for the output of processes a hook left running after it exited or was killed
+const hookWaitDelay = 2 * time.Second
+
+// Exec runs hookCommand on events, e.g. to play a sound or show a popup on the PC TUT runs on
+type Exec struct {
+	store   storage.Store
+	queue   chan tracker.Event
+	mu      sync.Mutex
+	cond    *sync.Cond
+	running int
+}
+
+// NewExec creates the command hook of store and starts running hooks in the background
+func NewExec(store storage.Store) *Exec {
+	h := &Exec{store: store, queue: make(chan tracker.Event, 100)}
+	h.cond = sync.NewCond(&h.mu)
+	go h.dispatch()
+	return h
+}
+
+// Notify queues a run of hookCommand when the type of e is listed in hookEvents or its user is watched, for use
+// with tracker.OnEvent
+func (h *Exec) Notify(e tracker.Event) {
+	var hook bool
+	h.store.View(func(tx storage.Tx) error {
+		hook = storage.Setting(tx, "hookCommand") != "" && (contains(splitList(storage.Setting(tx, "hookEvents")), e.Type) || tracker.Watched(e))
+		return nil
+	})
+	if !hook {
+		return
+	}
+	select {
+	case h.queue <- e:
+	default:
+		fmt.Printf("[SYS] Too many hooks queued, dropped %s of %s\n", e.Type, e.UserID)
+	}
+}
+
+// dispatch starts queued hooks, at most hookConcurrency at a time
+func (h *Exec) dispatch() {
+	for e := range h.queue {
+		var limit int
+		h.store.View(func(tx storage.Tx) error {
+			limit = storage.IntSetting(tx, "hookConcurrency")
+			return nil
+		})
+		if limit < 1 {
+			limit = 1
+		}
+
+		h.mu.Lock()
+		for h.running >= limit {
+			h.cond.Wait()
+		}
+		h.running++
+		h.mu.Unlock()
+
+		go func(e tracker.Event) {
+			defer func() {
+				h.mu.Lock()
+				h.running--
+				h.cond.Signal()
+				h.mu.Unlock()
+			}()
+			_, err := h.Run(e)
+			if err != nil {
+				fmt.Printf("[SYS] Hook of %s %s failed: %v\n", e.Type, e.UserID, err)
+			}
+		}(e)
+	}
+}
+
+// Run runs hookCommand for e and waits for it, killing it after hookTimeout seconds, and returns what it printed
+func (h *Exec) Run(e tracker.Event) ([]byte, error) {
+	var command string
+	var timeout int
+	h.store.View(func(tx storage.Tx) error {
+		command = storage.Setting(tx, "hookCommand")
+		timeout = storage.IntSetting(tx, "hookTimeout")
+		return nil
+	})
+	n := notice(h.store, e)
+	args, err := hookArgs(command, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Run: hookCommand is empty")
+	}
+	input, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	hookProcessGroup(cmd)
+	cmd.WaitDelay = hookWaitDelay
+	cmd.Env = append(os.Environ(), hookEnv(n)...)
+	cmd.Stdin = bytes.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("Run: %s timed out after %d seconds", args[0], timeout)
+	}
+	// A hook that exited fine may leave a process running in the background, e.g. "sh -c 'player sound.wav &'"
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if len(out) > 200 {
+			out = out[:200] + "..."
+		}
+		if out != "" {
+			return output, fmt.Errorf("Run: %s: %v: %s", args[0], err, out)
+		}
+		return output, fmt.Errorf("Run: %s: %v", args[0], err)
+	}
+	return output, nil
+}
+
+// hookArgs splits the command into arguments and then renders every argument as a template of n,
+// so event fields never change how the command is split and no shell is involved
+func hookArgs(command string, n Notice) ([]string, error) {
+	words, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for _, word := range words {
+		tmpl, err := template.New("hookCommand").Parse(word)
+		if err != nil {
+			return nil, err
+		}
+		var arg bytes.Buffer
+		err = tmpl.Execute(&arg, n)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// splitCommand splits a command line at spaces outside of single or double quotes, a backslash escapes
+// the next character outside of single quotes
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("splitCommand: unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// hookEnv passes the fields of n as TUT_* environment variables, event details as TUT_DETAIL_<KEY>
+func hookEnv(n Notice) []string {
+	env := []string{
+		"TUT_CHANNEL=" + n.Channel,
+		"TUT_EVENT=" + n.Event.Type,
+		"TUT_EVENT_ID=" + strconv.FormatUint(n.Event.ID, 10),
+		"TUT_USER_ID=" + n.User.ID,
+		"TUT_LOGIN=" + n.User.Login,
+		"TUT_DISPLAYNAME=" + n.User.Displayname,
+		"TUT_AT=" + n.User.At,
+		"TUT_FOLLOWED_AT=" + n.User.FollowedAt,
+	}
+	for k, v := range n.Event.Details {
+		env = append(env, "TUT_DETAIL_"+strings.ToUpper(k)+"="+v)
+	}
+	return env
+}
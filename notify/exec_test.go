@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+func TestHookArgs(t *testing.T) {
+	n := Notice{
+		Channel: "somechannel",
+		Event:   tracker.Event{ID: 7, Type: storage.EventUnfollow, UserID: "1", Details: map[string]string{"reason": "banned"}},
+		User:    User{ID: "1", Login: "alice", Displayname: `Alice "the" Great`, At: "2024-05-01T10:00:00Z"},
+	}
+
+	// Event fields never split or quote arguments, whatever they contain
+	args, err := hookArgs(`notify-send "{{.Event.Type}}: {{.User.Displayname}}" '{{.User.Login}} $HOME' --urgency=low\ 1`, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"notify-send", `unfollow: Alice "the" Great`, "alice $HOME", "--urgency=low 1"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("got %q, want %q", args, want)
+	}
+
+	for _, command := range []string{`say "unterminated`, `say trailing\`, `say {{.Nope`} {
+		if args, err := hookArgs(command, n); err == nil {
+			t.Errorf("%q: got %q, want an error", command, args)
+		}
+	}
+
+	env := hookEnv(n)
+	sort.Strings(env)
+	want = []string{
+		"TUT_AT=2024-05-01T10:00:00Z",
+		"TUT_CHANNEL=somechannel",
+		"TUT_DETAIL_REASON=banned",
+		`TUT_DISPLAYNAME=Alice "the" Great`,
+		"TUT_EVENT=unfollow",
+		"TUT_EVENT_ID=7",
+		"TUT_FOLLOWED_AT=",
+		"TUT_LOGIN=alice",
+		"TUT_USER_ID=1",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("got %q, want %q", env, want)
+	}
+}
+
+// testExec opens a store and an Exec running command
+func testExec(t *testing.T, command string, timeout string) *Exec {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests run sh")
+	}
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	err = store.Update(func(tx storage.Tx) error {
+		for k, v := range map[string]string{"username": "somechannel", "hookCommand": command, "hookTimeout": timeout} {
+			if err := tx.SetConfig(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewExec(store)
+}
+
+func TestRun(t *testing.T) {
+	h := testExec(t, `sh -c 'echo "$TUT_EVENT $1"; cat' hook {{.User.ID}}`, "10")
+	output, err := h.Run(tracker.Event{Type: storage.EventFollow, UserID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(output); !strings.HasPrefix(got, "follow 1\n{") || !strings.Contains(got, `"userID":"1"`) {
+		t.Errorf("got output %q, want the event type, argument and JSON on stdin", got)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	// The child of sh keeps the output open after sh was killed
+	h := testExec(t, `sh -c 'sleep 30 & sleep 30'`, "1")
+	start := time.Now()
+	_, err := h.Run(tracker.Event{Type: storage.EventFollow, UserID: "1"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got error %v, want a timeout", err)
+	}
+	if took := time.Since(start); took > 1*time.Second+hookWaitDelay+time.Second {
+		t.Errorf("Run took %s with a timeout of 1s", took.Round(time.Second))
+	}
+}
+
+func TestRunBackground(t *testing.T) {
+	// Playing a sound in the background is fine, the hook itself is done
+	h := testExec(t, `sh -c 'sleep 5 &'`, "30")
+	start := time.Now()
+	_, err := h.Run(tracker.Event{Type: storage.EventFollow, UserID: "1"})
+	if err != nil {
+		t.Errorf("got error %v for a hook that exited fine", err)
+	}
+	if took := time.Since(start); took > hookWaitDelay+time.Second {
+		t.Errorf("Run waited %s for a background process", took.Round(time.Second))
+	}
+}
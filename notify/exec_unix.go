@@ -0,0 +1,16 @@
+//go:build !windows
+
+package notify
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// hookProcessGroup starts the hook in a process group of its own, so a timeout kills whatever it started too
+func hookProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
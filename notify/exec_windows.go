@@ -0,0 +1,7 @@
+package notify
+
+import "os/exec"
+
+// hookProcessGroup leaves the hook as it is, a timeout kills the hook itself and WaitDelay stops waiting
+// for what it started
+func hookProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,93 @@
+// Package notify tells people what a tracker detected: by email right away or as daily and weekly digests,
+// by running local commands, and in Twitch chat.
+//
+//	mail := notify.NewEmail(store)
+//	t.OnEvent(mail.Notify)
+//	go mail.RunDigests()
+//	hooks := notify.NewExec(store)
+//	t.OnEvent(hooks.Notify)
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/tracker"
+)
+
+// User a user as templates see it, Profile holds every text field of the users bucket profile
+type User struct {
+	ID              string            `json:"id"`
+	Login           string            `json:"login"`
+	Displayname     string            `json:"displayname"`
+	ProfileImageURL string            `json:"profileImageURL"`
+	At              string            `json:"at"` // when TUT saw the event
+	FollowedAt      string            `json:"followedAt,omitempty"`
+	Roles           string            `json:"roles,omitempty"`  // e.g. "tier 3 sub, VIP"
+	Reason          string            `json:"reason,omitempty"` // why an unfollower is gone when it wasn't their choice, e.g. banned
+	Profile         map[string]string `json:"profile"`
+}
+
+// Notice one event as instant emails and hooks see it
+type Notice struct {
+	Channel string        `json:"channel"`
+	Event   tracker.Event `json:"event"`
+	User    User          `json:"user"`
+}
+
+// notice looks up the channel and the user of e
+func notice(store storage.Store, e tracker.Event) Notice {
+	n := Notice{Event: e}
+	store.View(func(tx storage.Tx) error {
+		n.Channel, _ = tx.Config("username")
+		n.User = templateUser(tx, e)
+		return nil
+	})
+	return n
+}
+
+// templateUser looks up the profile of the user of e, falling back to what the event knows
+func templateUser(tx storage.Tx, e tracker.Event) User {
+	u := User{ID: e.UserID, Login: e.Login, Displayname: e.Displayname, At: e.At, FollowedAt: e.FollowedAt, Reason: e.Details["reason"], Profile: map[string]string{}}
+	if record, ok := tx.User(e.UserID); ok {
+		var fields map[string]interface{}
+		if json.Unmarshal(record.User, &fields) == nil && fields["login"] != nil {
+			for k, v := range fields {
+				if s, ok := v.(string); ok {
+					u.Profile[k] = s
+				}
+			}
+			u.Login = u.Profile["login"]
+			u.Displayname = u.Profile["display_name"]
+			u.ProfileImageURL = u.Profile["profile_image_url"]
+		}
+	}
+	if u.Displayname == "" {
+		u.Displayname = u.ID
+	}
+	if roles := tracker.GetRoles(tx, e.UserID); roles != nil {
+		u.Roles = roles.String()
+	}
+	return u
+}
+
+// splitList splits a comma separated setting
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
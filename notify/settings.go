@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"github.com/devinjdawson/tut/storage"
+)
+
+func init() {
+	storage.SetDefaults(map[string]string{
+		"smtpHost":             "", // empty disables email
+		"smtpPort":             "25",
+		"smtpUser":             "", // empty sends without auth
+		"smtpPassword":         "",
+		"emailFrom":            "tut@localhost",
+		"emailTo":              "",    // comma separated
+		"emailInstant":         "",    // comma separated event types mailed right away, e.g. unfollow,refollow
+		"emailDigest":          "off", // off, daily or weekly
+		"emailDigestTime":      "09:00",
+		"emailDigestWeekday":   "Monday",
+		"emailTemplateDir":     "", // instant.tmpl and digest.tmpl replacing the built-in templates
+		"hookCommand":          "", // command run on events, arguments are templates of the event, empty disables hooks
+		"hookEvents":           "follow,refollow,unfollow",
+		"hookTimeout":          "10", // seconds
+		"hookConcurrency":      "2",
+		"chatFollow":           "false", // thank new followers in chat
+		"chatRefollow":         "false", // welcome back refollowers in chat
+		"chatFollowMessage":    "Thank you for the follow, {{.Names}}! <3",
+		"chatRefollowMessage":  "Welcome back, {{.Names}}!",
+		"chatMilestone":        "false", // celebrate reached follower milestones in chat
+		"chatMilestoneMessage": "We just reached {{.Milestone}} followers, thank you all! <3",
+		"chatBatch":            "30",  // seconds collecting events into one message
+		"chatCooldown":         "120", // seconds between messages
+		"chatUserCooldown":     "24",  // hours before chat names the same user again
+		"chatUser":             "",    // account posting, empty posts as the channel
+		"chatOAuth":            "",    // token of chatUser with the chat:edit scope, empty uses the OAuth token of TUT
+		"chatServer":           "irc.chat.twitch.tv:6697",
+		"chatTLS":              "true",
+	})
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// BackupName names a backup of s after the time it was taken, so that names sort by age
+func BackupName(s Store, t time.Time) string {
+	return fmt.Sprintf("TUT-%s%s", t.Format("20060102-150405"), backupExt(s))
+}
+
+// backupExt keeps the extension of the store so a backup is restored into the right kind of store
+func backupExt(s Store) string {
+	if _, ok := s.(*sqliteStore); ok {
+		return ".sqlite"
+	}
+	return ".db"
+}
+
+// BackupToFile writes a backup of s into path, through a temporary file so a failed backup leaves nothing behind
+func BackupToFile(s Store, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = s.Backup(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ListBackups finds backups of s in dir, oldest first
+func ListBackups(s Store, dir string) []string {
+	var backups []string
+	files, _ := ioutil.ReadDir(dir)
+	for _, f := range files {
+		if !f.IsDir() && strings.HasPrefix(f.Name(), "TUT-") && strings.HasSuffix(f.Name(), backupExt(s)) {
+			backups = append(backups, filepath.Join(dir, f.Name()))
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
+
+// RotateBackups removes the oldest backups of s in dir so that at most keep are left
+func RotateBackups(s Store, dir string, keep int) {
+	backups := ListBackups(s, dir)
+	for i := 0; i < len(backups)-keep; i++ {
+		fmt.Printf("[SYS] Removing old backup %s\n", backups[i])
+		os.Remove(backups[i])
+	}
+}
+
+// ValidateBackup checks that path is an intact database of the same kind as s, which this TUT can read
+func ValidateBackup(s Store, path string) error {
+	if _, ok := s.(*sqliteStore); ok {
+		return validateSQLiteBackup(path)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("ValidateBackup: %s is not a TUT database: %v", path, err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return fmt.Errorf("ValidateBackup: %s is corrupted: %v", path, err)
+		}
+		if tx.Bucket([]byte("config")) == nil || tx.Bucket([]byte("followers")) == nil {
+			return fmt.Errorf("ValidateBackup: %s has no TUT data", path)
+		}
+		if version, latest := schemaVersion(tx), migrations[len(migrations)-1].version; version > latest {
+			return fmt.Errorf("ValidateBackup: %s has schema version %d, this TUT only knows up to %d", path, version, latest)
+		}
+		return nil
+	})
+}
+
+// validateSQLiteBackup checks a SQLite backup the way ValidateBackup checks a bolt one
+func validateSQLiteBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("ValidateBackup: %s is not a TUT database: %v", path, err)
+	}
+	// Without file: the driver ignores mode=ro and would write -wal and -shm files next to the backup
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("ValidateBackup: %s is not a TUT database: %v", path, err)
+	}
+	defer db.Close()
+
+	var result string
+	err = db.QueryRow("PRAGMA integrity_check").Scan(&result)
+	if err != nil {
+		return fmt.Errorf("ValidateBackup: %s is not a TUT database: %v", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("ValidateBackup: %s is corrupted: %s", path, result)
+	}
+	var version int
+	db.QueryRow("PRAGMA user_version").Scan(&version)
+	if version == 0 {
+		return fmt.Errorf("ValidateBackup: %s has no TUT data", path)
+	}
+	if version > sqliteSchemaVersion {
+		return fmt.Errorf("ValidateBackup: %s has schema version %d, this TUT only knows up to %d", path, version, sqliteSchemaVersion)
+	}
+	return nil
+}
+
+// RestoreBackup validates a backup and swaps it in place of the database of s, keeping the replaced database.
+// A SQLite store is closed, bolt only locks its file while it is used. Databases another process still has open
+// are not replaced.
+func RestoreBackup(s Store, path string) (string, error) {
+	err := ValidateBackup(s, path)
+	if err != nil {
+		return "", err
+	}
+	dbPath := s.Path()
+	previous := fmt.Sprintf("%s.pre-restore-%s.bak", dbPath, time.Now().Format("20060102150405"))
+
+	if _, ok := s.(*sqliteStore); ok {
+		// SQLite is kept open, close it so nothing writes to the old file any more
+		f, err := os.Create(previous)
+		if err != nil {
+			return "", err
+		}
+		_, err = s.Backup(f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		s.Close()
+		// Closing the last connection checkpoints and removes the WAL, one that is left belongs to another process
+		if info, err := os.Stat(dbPath + "-wal"); err == nil && info.Size() > 0 {
+			os.Remove(previous)
+			return "", errors.New("RestoreBackup: " + dbPath + " is still open in another process, please stop TUT first")
+		}
+		os.Remove(dbPath + "-wal")
+		os.Remove(dbPath + "-shm")
+	} else {
+		// TUT holds the database lock only while it reads or writes, retry for a while
+		db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 10 * time.Second})
+		if err != nil {
+			return "", errors.New("RestoreBackup: cannot lock " + dbPath + ", please stop TUT first")
+		}
+		defer db.Close()
+
+		err = db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(previous, 0600)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Copy next to the database first, rename is atomic
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	tmp := dbPath + ".restore"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return previous, os.Rename(tmp, dbPath)
+}
@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltStore keeps everything in buckets of a bolt file. Like TUT always did, the file is only
+// opened for the duration of a transaction so other TUT commands can use it in between.
+type boltStore struct {
+	path string
+}
+
+// Buckets that are not generic key / value buckets
+var boltReservedBuckets = map[string]bool{
+	"config": true, "meta": true, "users": true, "logins": true, "events": true, "userEvents": true,
+	"followers": true, "following": true, "unfollowers": true, "unfollowing": true, "notfollower": true,
+}
+
+// openBoltStore opens a bolt file and upgrades it to the latest schema version
+func openBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return &boltStore{path}, migrateDB(db)
+}
+
+func (s *boltStore) View(fn func(tx Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx})
+	})
+}
+
+func (s *boltStore) Update(fn func(tx Tx) error) error {
+	db, err := bolt.Open(s.path, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx})
+	})
+}
+
+func (s *boltStore) Backup(w io.Writer) (int64, error) {
+	dir, err := ioutil.TempDir("", "tut-backup")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The file stays locked only while it is copied, not while a slow client downloads it
+	tmp := filepath.Join(dir, "backup.db")
+	db, err := bolt.Open(s.path, 0600, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(tmp, 0600)
+	})
+	db.Close()
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+func (s *boltStore) Path() string {
+	return s.path
+}
+
+func (s *boltStore) Close() error {
+	return nil
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// bucket finds a bucket, creating it within writable transactions
+func (t *boltTx) bucket(name string) *bolt.Bucket {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil && t.tx.Writable() {
+		b, _ = t.tx.CreateBucketIfNotExists([]byte(name))
+	}
+	return b
+}
+
+func (t *boltTx) Config(key string) (string, bool) {
+	b := t.bucket("config")
+	if b == nil {
+		return "", false
+	}
+	v := b.Get([]byte(key))
+	return string(v), v != nil
+}
+
+func (t *boltTx) SetConfig(key string, value string) error {
+	return t.bucket("config").Put([]byte(key), []byte(value))
+}
+
+func (t *boltTx) DeleteConfig(key string) error {
+	return t.bucket("config").Delete([]byte(key))
+}
+
+func (t *boltTx) ForEachConfig(fn func(key string, value string) error) error {
+	b := t.bucket("config")
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), string(v))
+	})
+}
+
+func (t *boltTx) Relation(list string, uid string) string {
+	b := t.bucket(list)
+	if b == nil {
+		return ""
+	}
+	return relationAt(b.Get([]byte(uid)))
+}
+
+func (t *boltTx) PutRelation(list string, uid string, at string) error {
+	return t.bucket(list).Put([]byte(uid), newRelation(at))
+}
+
+func (t *boltTx) DeleteRelation(list string, uid string) error {
+	return t.bucket(list).Delete([]byte(uid))
+}
+
+func (t *boltTx) ForEachRelation(list string, fn func(uid string, at string) error) error {
+	b := t.bucket(list)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), relationAt(v))
+	})
+}
+
+func (t *boltTx) CountRelations(list string) int {
+	b := t.bucket(list)
+	if b == nil {
+		return 0
+	}
+	return b.Stats().KeyN
+}
+
+func (t *boltTx) User(uid string) (UserRecord, bool) {
+	b := t.bucket("users")
+	if b == nil {
+		return UserRecord{}, false
+	}
+	data := b.Get([]byte(uid))
+	if data == nil {
+		return UserRecord{}, false
+	}
+	return decodeUser(data), true
+}
+
+func (t *boltTx) PutUser(uid string, u UserRecord) error {
+	users := t.bucket("users")
+	logins := t.bucket("logins")
+
+	// Drop the index entry of the previous login, the user may have been renamed
+	if old, ok := ParseUserProfile(userJSON(users.Get([]byte(uid)))); ok && old["login"] != "" {
+		login := []byte(strings.ToLower(old["login"]))
+		if string(logins.Get(login)) == uid {
+			err := logins.Delete(login)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := users.Put([]byte(uid), encodeUser(u))
+	if err != nil {
+		return err
+	}
+	if profile, ok := ParseUserProfile(u.User); ok && profile["login"] != "" {
+		return logins.Put([]byte(strings.ToLower(profile["login"])), []byte(uid))
+	}
+	return nil
+}
+
+func (t *boltTx) ForEachUser(fn func(uid string, u UserRecord) error) error {
+	b := t.bucket("users")
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), decodeUser(v))
+	})
+}
+
+func (t *boltTx) LookupLogin(login string) string {
+	b := t.bucket("logins")
+	if b == nil {
+		return ""
+	}
+	return string(b.Get([]byte(strings.ToLower(login))))
+}
+
+func (t *boltTx) SearchLogins(prefix string, limit int) []string {
+	var uids []string
+	b := t.bucket("logins")
+	if b == nil {
+		return uids
+	}
+	p := strings.ToLower(prefix)
+	c := b.Cursor()
+	for k, v := c.Seek([]byte(p)); k != nil && strings.HasPrefix(string(k), p); k, v = c.Next() {
+		if len(uids) >= limit {
+			break
+		}
+		uids = append(uids, string(v))
+	}
+	return uids
+}
+
+// eventKey encodes event ID so that keys sort in insertion order
+func eventKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func (t *boltTx) AppendEvent(e Event) (uint64, error) {
+	id, err := t.bucket("events").NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	e.ID = id
+	return id, t.PutEvent(e)
+}
+
+func (t *boltTx) PutEvent(e Event) error {
+	b := t.bucket("events")
+	if b.Sequence() < e.ID {
+		err := b.SetSequence(e.ID)
+		if err != nil {
+			return err
+		}
+	}
+	e.V = eventVersion
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	err = b.Put(eventKey(e.ID), data)
+	if err != nil {
+		return err
+	}
+
+	// Index event under user so that a timeline doesn't need to scan every event
+	u, err := t.bucket("userEvents").CreateBucketIfNotExists([]byte(e.UserID))
+	if err != nil {
+		return err
+	}
+	return u.Put(eventKey(e.ID), []byte{})
+}
+
+func (t *boltTx) ForEachEvent(fn func(e Event) error) error {
+	b := t.bucket("events")
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(_, v []byte) error {
+		var e Event
+		if json.Unmarshal(v, &e) != nil {
+			return nil
+		}
+		return fn(e)
+	})
+}
+
+func (t *boltTx) UserEvents(uid string) []Event {
+	var events []Event
+	b := t.bucket("events")
+	ue := t.bucket("userEvents")
+	if b == nil || ue == nil {
+		return events
+	}
+	u := ue.Bucket([]byte(uid))
+	if u == nil {
+		return events
+	}
+	u.ForEach(func(k, _ []byte) error {
+		var e Event
+		if json.Unmarshal(b.Get(k), &e) == nil {
+			events = append(events, e)
+		}
+		return nil
+	})
+	return events
+}
+
+func (t *boltTx) Get(bucket string, key string) []byte {
+	b := t.bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	v := b.Get([]byte(key))
+	if v == nil {
+		return nil
+	}
+	// bolt values are only valid during the transaction
+	return append([]byte{}, v...)
+}
+
+func (t *boltTx) Put(bucket string, key string, value []byte) error {
+	return t.bucket(bucket).Put([]byte(key), value)
+}
+
+func (t *boltTx) Delete(bucket string, key string) error {
+	return t.bucket(bucket).Delete([]byte(key))
+}
+
+func (t *boltTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	b := t.bucket(bucket)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), append([]byte{}, v...))
+	})
+}
+
+func (t *boltTx) Buckets() []string {
+	var buckets []string
+	t.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if !boltReservedBuckets[string(name)] {
+			buckets = append(buckets, string(name))
+		}
+		return nil
+	})
+	return buckets
+}
+
+// rebuildLoginIndex fills logins bucket from every entry of users bucket
+func rebuildLoginIndex(tx *bolt.Tx) error {
+	u := tx.Bucket([]byte("users"))
+	l := tx.Bucket([]byte("logins"))
+	return u.ForEach(func(k, v []byte) error {
+		profile, ok := ParseUserProfile(userJSON(v))
+		if !ok || profile["login"] == "" {
+			return nil
+		}
+		return l.Put([]byte(strings.ToLower(profile["login"])), k)
+	})
+}
@@ -0,0 +1,30 @@
+package storage
+
+// Event types recorded in events bucket
+const (
+	EventFollow     = "follow"
+	EventRefollow   = "refollow"
+	EventUnfollow   = "unfollow"
+	EventFollows    = "follows"
+	EventRefollowed = "refollowed"
+	EventUnfollowed = "unfollowed"
+	EventProfile    = "profile"
+	EventEnrichment = "enrichment"
+	EventSuspicious = "suspicious"
+	EventBaseline   = "baseline"
+	EventMilestone  = "milestone"
+	EventAccount    = "account"
+)
+
+// Event something TUT detected about a user
+type Event struct {
+	V           int               `json:"v"`
+	ID          uint64            `json:"id"`
+	Type        string            `json:"type"`
+	UserID      string            `json:"userID"`
+	Login       string            `json:"login,omitempty"`
+	Displayname string            `json:"displayname,omitempty"`
+	At          string            `json:"at"`
+	FollowedAt  string            `json:"followedAt,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
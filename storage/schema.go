@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/boltdb/bolt"
+)
+
+// migration upgrades the database by one schema version
+type migration struct {
+	version     int
+	description string
+	up          func(tx *bolt.Tx) error
+}
+
+// migrations in order, append new ones, never change a released one
+var migrations = []migration{
+	{1, "create relationship and users buckets", createBaseBuckets},
+	{2, "create login index, event history and streams buckets", createIndexBuckets},
+	{3, "encode relationship and users values as versioned JSON", encodeValues},
+}
+
+// schemaVersion reads the schema version of the database, 0 for databases from before schema versions
+func schemaVersion(tx *bolt.Tx) int {
+	m := tx.Bucket([]byte("meta"))
+	if m == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(string(m.Get([]byte("schemaVersion"))))
+	return version
+}
+
+// migrateDB upgrades a bolt database to the latest schema version, backing it up first
+func migrateDB(db *bolt.DB) error {
+	latest := migrations[len(migrations)-1].version
+
+	var version int
+	var empty bool
+	db.View(func(tx *bolt.Tx) error {
+		version = schemaVersion(tx)
+		empty = tx.Bucket([]byte("followers")) == nil
+		return nil
+	})
+	if version > latest {
+		return fmt.Errorf("migrateDB: %s has schema version %d, this TUT only knows up to %d, please update TUT", db.Path(), version, latest)
+	}
+	if version == latest {
+		return nil
+	}
+
+	if !empty {
+		backup := fmt.Sprintf("%s.schema%d-%s.bak", db.Path(), version, time.Now().Format("20060102150405"))
+		fmt.Printf("[SYS] Backing up database to %s before upgrading schema...\n", backup)
+		err := db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(backup, 0600)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		fmt.Printf("[SYS] Upgrading database schema to v%d, %s...\n", m.version, m.description)
+		err := db.Update(func(tx *bolt.Tx) error {
+			err := m.up(tx)
+			if err != nil {
+				return err
+			}
+			meta, err := tx.CreateBucketIfNotExists([]byte("meta"))
+			if err != nil {
+				return err
+			}
+			return meta.Put([]byte("schemaVersion"), []byte(strconv.Itoa(m.version)))
+		})
+		if err != nil {
+			return fmt.Errorf("migrateDB: upgrade to schema v%d failed: %v", m.version, err)
+		}
+	}
+	return nil
+}
+
+func createBaseBuckets(tx *bolt.Tx) error {
+	// notfollower used to be created by checking for a misspelled "nowfollower" bucket
+	for _, name := range []string{"followers", "following", "unfollowers", "unfollowing", "notfollower", "users"} {
+		_, err := tx.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return err
+		}
+	}
+	if tx.Bucket([]byte("nowfollower")) != nil {
+		return tx.DeleteBucket([]byte("nowfollower"))
+	}
+	return nil
+}
+
+func createIndexBuckets(tx *bolt.Tx) error {
+	for _, name := range []string{"events", "userEvents", "streams"} {
+		_, err := tx.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return err
+		}
+	}
+	if tx.Bucket([]byte("logins")) != nil {
+		return nil
+	}
+	_, err := tx.CreateBucket([]byte("logins"))
+	if err != nil {
+		return err
+	}
+	return rebuildLoginIndex(tx)
+}
+
+func encodeValues(tx *bolt.Tx) error {
+	for _, name := range []string{"followers", "following", "unfollowers", "unfollowing"} {
+		err := rewriteBucket(tx.Bucket([]byte(name)), func(v []byte) []byte {
+			return encodeRelation(decodeRelation(v))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return rewriteBucket(tx.Bucket([]byte("users")), func(v []byte) []byte {
+		return encodeUser(decodeUser(v))
+	})
+}
+
+// rewriteBucket replaces every value of a bucket
+func rewriteBucket(b *bolt.Bucket, rewrite func(v []byte) []byte) error {
+	values := make(map[string][]byte)
+	b.ForEach(func(k, v []byte) error {
+		values[string(k)] = rewrite(v)
+		return nil
+	})
+	for k, v := range values {
+		err := b.Put([]byte(k), v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Versions of value encodings, bump when a field changes meaning
+const (
+	relationVersion = 1
+	UserVersion     = 1
+	eventVersion    = 1
+)
+
+// relationRecord value of followers, following, unfollowers and unfollowing buckets
+type relationRecord struct {
+	V  int    `json:"v"`
+	At string `json:"at"`
+}
+
+// UserRecord value of users bucket
+type UserRecord struct {
+	V         int             `json:"v"`
+	FetchedAt string          `json:"fetchedAt,omitempty"`
+	User      json.RawMessage `json:"user,omitempty"`
+}
+
+func encodeRelation(r relationRecord) []byte {
+	r.V = relationVersion
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// decodeRelation reads a relationship value, schema v2 and older stored a bare RFC3339 time
+func decodeRelation(data []byte) relationRecord {
+	var r relationRecord
+	if len(data) > 0 && data[0] == '{' && json.Unmarshal(data, &r) == nil {
+		return r
+	}
+	return relationRecord{At: string(data)}
+}
+
+// relationAt reads the time of a relationship value, empty if there is none
+func relationAt(data []byte) string {
+	if data == nil {
+		return ""
+	}
+	return decodeRelation(data).At
+}
+
+// newRelation encodes a relationship value for time at
+func newRelation(at string) []byte {
+	return encodeRelation(relationRecord{At: at})
+}
+
+func encodeUser(u UserRecord) []byte {
+	u.V = UserVersion
+	data, _ := json.Marshal(u)
+	return data
+}
+
+// decodeUser reads a users value, schema v2 and older stored the bare Helix user JSON
+func decodeUser(data []byte) UserRecord {
+	var u UserRecord
+	if len(data) > 0 && json.Unmarshal(data, &u) == nil && u.V > 0 {
+		return u
+	}
+	if json.Valid(data) {
+		u.User = json.RawMessage(data)
+	}
+	return u
+}
+
+// NewUserRecord makes a users value for Helix user JSON fetched now
+func NewUserRecord(helix []byte) UserRecord {
+	u := UserRecord{V: UserVersion, FetchedAt: time.Now().UTC().Format(time.RFC3339)}
+	if len(helix) > 0 && json.Valid(helix) {
+		u.User = json.RawMessage(helix)
+	}
+	return u
+}
+
+// userJSON reads the Helix user JSON of a users value, nil if the user couldn't be fetched
+func userJSON(data []byte) []byte {
+	return decodeUser(data).User
+}
+
+// ParseUserProfile reads id, login, display name and avatar from Helix user JSON
+func ParseUserProfile(data []byte) (map[string]string, bool) {
+	if len(data) == 0 {
+		return nil, false
+	}
+	parsed, err := gabs.ParseJSON(data)
+	if err != nil {
+		return nil, false
+	}
+	userdata, err := parsed.ChildrenMap()
+	if err != nil {
+		return nil, false
+	}
+	profile := make(map[string]string)
+	for _, key := range []string{"id", "login", "display_name", "profile_image_url"} {
+		if child, ok := userdata[key]; ok {
+			profile[key], _ = child.Data().(string)
+		}
+	}
+	return profile, true
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"sort"
+	"strconv"
+)
+
+// settings are config bucket keys that are not asked for at start up, with their defaults.
+// Packages add theirs with SetDefaults, change them with "tut config <key> <value>".
+var settings = map[string]string{}
+
+// SetDefaults adds settings with their defaults
+func SetDefaults(defaults map[string]string) {
+	for k, v := range defaults {
+		settings[k] = v
+	}
+}
+
+// IsSetting tells whether key is a known setting
+func IsSetting(key string) bool {
+	_, ok := settings[key]
+	return ok
+}
+
+// Settings lists the keys of all settings, sorted
+func Settings() []string {
+	var keys []string
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Setting reads a setting from config bucket, falling back to its default
+func Setting(tx Tx, key string) string {
+	if v, ok := tx.Config(key); ok {
+		return v
+	}
+	return settings[key]
+}
+
+// IntSetting reads a numeric setting, falling back to its default when it is not a number
+func IntSetting(tx Tx, key string) int {
+	n, err := strconv.Atoi(Setting(tx, key))
+	if err != nil {
+		n, _ = strconv.Atoi(settings[key])
+	}
+	return n
+}
+
+// BoolSetting reads a true / false setting
+func BoolSetting(tx Tx, key string) bool {
+	enabled, _ := strconv.ParseBool(Setting(tx, key))
+	return enabled
+}
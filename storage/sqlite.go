@@ -0,0 +1,377 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Pure Go SQLite driver, registers "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchemaVersion is stored in PRAGMA user_version, bump it together with a new step in sqliteSchema
+const sqliteSchemaVersion = 1
+
+// sqliteSchema creates tables and indexes, step i upgrades user_version i to i+1
+var sqliteSchema = []string{
+	`CREATE TABLE config (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	CREATE TABLE relationships (
+		list TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		at TEXT NOT NULL,
+		PRIMARY KEY (list, user_id)
+	);
+	CREATE INDEX relationships_user ON relationships (user_id);
+	CREATE INDEX relationships_at ON relationships (list, at);
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		login TEXT,
+		fetched_at TEXT,
+		data TEXT
+	);
+	CREATE INDEX users_login ON users (login);
+	CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		at TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX events_user ON events (user_id, id);
+	CREATE INDEX events_type_at ON events (type, at);
+	CREATE TABLE kv (
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value BLOB NOT NULL,
+		PRIMARY KEY (bucket, key)
+	);`,
+}
+
+// sqliteStore keeps relationships, users and events in tables of a SQLite file,
+// unlike bolt several processes can use it at the same time
+type sqliteStore struct {
+	path string
+	db   *sql.DB
+}
+
+// openSQLiteStore opens a SQLite file and creates or upgrades its tables
+func openSQLiteStore(path string) (Store, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	err = db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if version > sqliteSchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("openSQLiteStore: %s has schema version %d, this TUT only knows up to %d, please update TUT", path, version, sqliteSchemaVersion)
+	}
+	for ; version < sqliteSchemaVersion; version++ {
+		_, err = db.Exec(sqliteSchema[version] + fmt.Sprintf("\nPRAGMA user_version = %d;", version+1))
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("openSQLiteStore: upgrade to schema v%d failed: %v", version+1, err)
+		}
+	}
+	return &sqliteStore{path, db}, nil
+}
+
+func (s *sqliteStore) View(fn func(tx Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(&sqliteTx{tx})
+}
+
+func (s *sqliteStore) Update(fn func(tx Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	err = fn(&sqliteTx{tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Backup vacuums a consistent copy into a temporary file and streams it
+func (s *sqliteStore) Backup(w io.Writer) (int64, error) {
+	dir, err := ioutil.TempDir("", "tut-backup")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "backup.sqlite")
+	_, err = s.db.Exec("VACUUM INTO ?", tmp)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Open(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+func (s *sqliteStore) Path() string {
+	return s.path
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+type sqliteTx struct {
+	tx *sql.Tx
+}
+
+// queryStrings reads every row of a query first, callers may write within the same transaction
+func (t *sqliteTx) queryStrings(query string, args ...interface{}) [][]string {
+	var rows [][]string
+	r, err := t.tx.Query(query, args...)
+	if err != nil {
+		return rows
+	}
+	defer r.Close()
+	columns, _ := r.Columns()
+	for r.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if r.Scan(dest...) != nil {
+			continue
+		}
+		row := make([]string, len(columns))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (t *sqliteTx) Config(key string) (string, bool) {
+	var value string
+	err := t.tx.QueryRow("SELECT value FROM config WHERE key = ?", key).Scan(&value)
+	return value, err == nil
+}
+
+func (t *sqliteTx) SetConfig(key string, value string) error {
+	_, err := t.tx.Exec("INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)", key, value)
+	return err
+}
+
+func (t *sqliteTx) DeleteConfig(key string) error {
+	_, err := t.tx.Exec("DELETE FROM config WHERE key = ?", key)
+	return err
+}
+
+func (t *sqliteTx) ForEachConfig(fn func(key string, value string) error) error {
+	for _, row := range t.queryStrings("SELECT key, value FROM config ORDER BY key") {
+		err := fn(row[0], row[1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqliteTx) Relation(list string, uid string) string {
+	var at string
+	t.tx.QueryRow("SELECT at FROM relationships WHERE list = ? AND user_id = ?", list, uid).Scan(&at)
+	return at
+}
+
+func (t *sqliteTx) PutRelation(list string, uid string, at string) error {
+	_, err := t.tx.Exec("INSERT OR REPLACE INTO relationships (list, user_id, at) VALUES (?, ?, ?)", list, uid, at)
+	return err
+}
+
+func (t *sqliteTx) DeleteRelation(list string, uid string) error {
+	_, err := t.tx.Exec("DELETE FROM relationships WHERE list = ? AND user_id = ?", list, uid)
+	return err
+}
+
+func (t *sqliteTx) ForEachRelation(list string, fn func(uid string, at string) error) error {
+	for _, row := range t.queryStrings("SELECT user_id, at FROM relationships WHERE list = ? ORDER BY user_id", list) {
+		err := fn(row[0], row[1])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqliteTx) CountRelations(list string) int {
+	var n int
+	t.tx.QueryRow("SELECT COUNT(*) FROM relationships WHERE list = ?", list).Scan(&n)
+	return n
+}
+
+// sqliteUser reads a users row
+func sqliteUser(fetchedAt string, data string) UserRecord {
+	u := UserRecord{V: UserVersion, FetchedAt: fetchedAt}
+	if data != "" {
+		u.User = json.RawMessage(data)
+	}
+	return u
+}
+
+func (t *sqliteTx) User(uid string) (UserRecord, bool) {
+	var fetchedAt, data sql.NullString
+	err := t.tx.QueryRow("SELECT fetched_at, data FROM users WHERE id = ?", uid).Scan(&fetchedAt, &data)
+	if err != nil {
+		return UserRecord{}, false
+	}
+	return sqliteUser(fetchedAt.String, data.String), true
+}
+
+func (t *sqliteTx) PutUser(uid string, u UserRecord) error {
+	var login interface{}
+	if profile, ok := ParseUserProfile(u.User); ok && profile["login"] != "" {
+		login = strings.ToLower(profile["login"])
+	}
+	var data interface{}
+	if len(u.User) > 0 {
+		data = string(u.User)
+	}
+	_, err := t.tx.Exec("INSERT OR REPLACE INTO users (id, login, fetched_at, data) VALUES (?, ?, ?, ?)", uid, login, u.FetchedAt, data)
+	return err
+}
+
+func (t *sqliteTx) ForEachUser(fn func(uid string, u UserRecord) error) error {
+	for _, row := range t.queryStrings("SELECT id, fetched_at, data FROM users ORDER BY id") {
+		err := fn(row[0], sqliteUser(row[1], row[2]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqliteTx) LookupLogin(login string) string {
+	var uid string
+	t.tx.QueryRow("SELECT id FROM users WHERE login = ?", strings.ToLower(login)).Scan(&uid)
+	return uid
+}
+
+func (t *sqliteTx) SearchLogins(prefix string, limit int) []string {
+	var uids []string
+	p := strings.ToLower(prefix)
+	// A range over the index instead of LIKE, which can't use it
+	for _, row := range t.queryStrings("SELECT id FROM users WHERE login >= ? AND login < ? ORDER BY login LIMIT ?", p, p+"\U0010FFFF", limit) {
+		uids = append(uids, row[0])
+	}
+	return uids
+}
+
+func (t *sqliteTx) AppendEvent(e Event) (uint64, error) {
+	e.V = eventVersion
+	e.ID = 0
+	data, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	result, err := t.tx.Exec("INSERT INTO events (type, user_id, at, data) VALUES (?, ?, ?, ?)", e.Type, e.UserID, e.At, string(data))
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return uint64(id), err
+}
+
+func (t *sqliteTx) PutEvent(e Event) error {
+	id := e.ID
+	e.V = eventVersion
+	e.ID = 0
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// AUTOINCREMENT continues after the highest ID ever stored
+	_, err = t.tx.Exec("INSERT OR REPLACE INTO events (id, type, user_id, at, data) VALUES (?, ?, ?, ?, ?)", id, e.Type, e.UserID, e.At, string(data))
+	return err
+}
+
+// sqliteEvents reads events rows of id and data
+func sqliteEvents(rows [][]string) []Event {
+	var events []Event
+	for _, row := range rows {
+		var e Event
+		if json.Unmarshal([]byte(row[1]), &e) != nil {
+			continue
+		}
+		fmt.Sscan(row[0], &e.ID)
+		events = append(events, e)
+	}
+	return events
+}
+
+func (t *sqliteTx) ForEachEvent(fn func(e Event) error) error {
+	for _, e := range sqliteEvents(t.queryStrings("SELECT id, data FROM events ORDER BY id")) {
+		err := fn(e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqliteTx) UserEvents(uid string) []Event {
+	return sqliteEvents(t.queryStrings("SELECT id, data FROM events WHERE user_id = ? ORDER BY id", uid))
+}
+
+func (t *sqliteTx) Get(bucket string, key string) []byte {
+	var value []byte
+	t.tx.QueryRow("SELECT value FROM kv WHERE bucket = ? AND key = ?", bucket, key).Scan(&value)
+	return value
+}
+
+func (t *sqliteTx) Put(bucket string, key string, value []byte) error {
+	_, err := t.tx.Exec("INSERT OR REPLACE INTO kv (bucket, key, value) VALUES (?, ?, ?)", bucket, key, value)
+	return err
+}
+
+func (t *sqliteTx) Delete(bucket string, key string) error {
+	_, err := t.tx.Exec("DELETE FROM kv WHERE bucket = ? AND key = ?", bucket, key)
+	return err
+}
+
+func (t *sqliteTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	for _, row := range t.queryStrings("SELECT key, value FROM kv WHERE bucket = ? ORDER BY key", bucket) {
+		err := fn(row[0], []byte(row[1]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqliteTx) Buckets() []string {
+	var buckets []string
+	for _, row := range t.queryStrings("SELECT DISTINCT bucket FROM kv ORDER BY bucket") {
+		buckets = append(buckets, row[0])
+	}
+	return buckets
+}
@@ -0,0 +1,139 @@
+// Package storage keeps what TUT tracks, relationship lists, users, events and config, in bolt or SQLite.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Relationship lists of the tracked channel
+const (
+	ListFollowers   = "followers"
+	ListFollowing   = "following"
+	ListUnfollowers = "unfollowers"
+	ListUnfollowing = "unfollowing"
+)
+
+// Default paths of the stores
+const (
+	DefaultDBName     = "TUT.db"
+	DefaultSQLiteName = "TUT.sqlite"
+)
+
+// Lists every relationship list
+var Lists = []string{ListFollowers, ListFollowing, ListUnfollowers, ListUnfollowing}
+
+// Store keeps everything TUT tracks, either in a bolt file (TUT.db) or in SQLite
+type Store interface {
+	View(fn func(tx Tx) error) error
+	Update(fn func(tx Tx) error) error
+	// Backup writes a consistent copy of the store
+	Backup(w io.Writer) (int64, error)
+	Path() string
+	Close() error
+}
+
+// Tx reads and writes a store within one transaction
+type Tx interface {
+	Config(key string) (string, bool)
+	SetConfig(key string, value string) error
+	DeleteConfig(key string) error
+	ForEachConfig(fn func(key string, value string) error) error
+
+	// Relation finds when a user was added to a relationship list, empty if the user is not in it
+	Relation(list string, uid string) string
+	PutRelation(list string, uid string, at string) error
+	DeleteRelation(list string, uid string) error
+	ForEachRelation(list string, fn func(uid string, at string) error) error
+	CountRelations(list string) int
+
+	User(uid string) (UserRecord, bool)
+	// PutUser stores a user and keeps the login index in sync
+	PutUser(uid string, u UserRecord) error
+	ForEachUser(fn func(uid string, u UserRecord) error) error
+	LookupLogin(login string) string
+	SearchLogins(prefix string, limit int) []string
+
+	// AppendEvent stores an event and returns its ID, IDs grow in insertion order
+	AppendEvent(e Event) (uint64, error)
+	// PutEvent stores an event under its own ID, later appends get higher IDs
+	PutEvent(e Event) error
+	ForEachEvent(fn func(e Event) error) error
+	UserEvents(uid string) []Event
+
+	// Smaller data without a table of its own, e.g. stream sessions, lives in named buckets
+	Get(bucket string, key string) []byte
+	Put(bucket string, key string, value []byte) error
+	Delete(bucket string, key string) error
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+	Buckets() []string
+}
+
+// Open opens a store described by kind ("bolt" or "sqlite") and path
+func Open(kind string, path string) (Store, error) {
+	switch kind {
+	case "bolt":
+		return openBoltStore(path)
+	case "sqlite":
+		return openSQLiteStore(path)
+	}
+	return nil, fmt.Errorf("Open: unknown store %q, use bolt or sqlite", kind)
+}
+
+// ParseSpec splits "kind:path", e.g. "sqlite:TUT.sqlite", a bare kind uses its default path
+func ParseSpec(spec string) (string, string) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], DefaultPath(parts[0])
+}
+
+// DefaultPath the path of a store kind when none is given
+func DefaultPath(kind string) string {
+	if kind == "sqlite" {
+		return DefaultSQLiteName
+	}
+	return DefaultDBName
+}
+
+// Copy copies everything from one store into another, e.g. from bolt to SQLite
+func Copy(from Store, to Store) error {
+	return from.View(func(src Tx) error {
+		return to.Update(func(dst Tx) error {
+			err := src.ForEachConfig(dst.SetConfig)
+			if err != nil {
+				return err
+			}
+			for _, list := range Lists {
+				err = src.ForEachRelation(list, func(uid string, at string) error {
+					return dst.PutRelation(list, uid, at)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			err = src.ForEachUser(func(uid string, u UserRecord) error {
+				return dst.PutUser(uid, u)
+			})
+			if err != nil {
+				return err
+			}
+			// Events keep their IDs, cursors such as the last event of the email digest point at them
+			err = src.ForEachEvent(dst.PutEvent)
+			if err != nil {
+				return err
+			}
+			for _, bucket := range src.Buckets() {
+				err = src.ForEach(bucket, func(key string, value []byte) error {
+					return dst.Put(bucket, key, value)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
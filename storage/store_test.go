@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func eventIDs(t *testing.T, s Store) []uint64 {
+	t.Helper()
+	var ids []uint64
+	err := s.View(func(tx Tx) error {
+		return tx.ForEachEvent(func(e Event) error {
+			if e.ID == 0 {
+				t.Errorf("event %s of %s has no ID", e.Type, e.UserID)
+			}
+			ids = append(ids, e.ID)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestCopyKeepsEventIDs(t *testing.T) {
+	dir := t.TempDir()
+	src, err := Open("bolt", filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	// Gaps in the IDs, as left by an earlier migration, must survive the copy
+	err = src.Update(func(tx Tx) error {
+		for _, e := range []Event{{Type: EventFollow, UserID: "1"}, {Type: EventUnfollow, UserID: "2"}} {
+			if _, err := tx.AppendEvent(e); err != nil {
+				return err
+			}
+		}
+		if err := tx.PutEvent(Event{ID: 10, Type: EventRefollow, UserID: "2"}); err != nil {
+			return err
+		}
+		_, err := tx.AppendEvent(Event{Type: EventFollow, UserID: "3"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{1, 2, 10, 11}
+	if got := eventIDs(t, src); !reflect.DeepEqual(got, want) {
+		t.Fatalf("source IDs %v, want %v", got, want)
+	}
+
+	mid, err := Open("sqlite", filepath.Join(dir, "mid.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mid.Close()
+	dst, err := Open("bolt", filepath.Join(dir, "dst.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	for _, step := range []struct {
+		name     string
+		from, to Store
+	}{{"bolt to sqlite", src, mid}, {"sqlite to bolt", mid, dst}} {
+		if err := Copy(step.from, step.to); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := eventIDs(t, step.to); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: IDs %v, want %v", step.name, got, want)
+		}
+
+		// Events appended after the copy continue after the highest ID
+		next := want[len(want)-1] + 1
+		var id uint64
+		err = step.to.Update(func(tx Tx) error {
+			var err error
+			id, err = tx.AppendEvent(Event{Type: EventFollow, UserID: "4"})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != next {
+			t.Errorf("%s: next event got ID %d, want %d", step.name, id, next)
+		}
+		want = append(want, id)
+
+		var timeline []Event
+		step.to.View(func(tx Tx) error {
+			timeline = tx.UserEvents("2")
+			return nil
+		})
+		if len(timeline) != 2 || timeline[0].ID != 2 || timeline[1].ID != 10 {
+			t.Errorf("%s: events of user 2 %+v, want IDs 2 and 10", step.name, timeline)
+		}
+	}
+}
+
+// blockingWriter holds every write until release is closed, like a stalled download, and tells when
+// the first one arrived
+type blockingWriter struct {
+	started chan struct{}
+	once    *sync.Once
+	release chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	return len(p), nil
+}
+
+func TestBackupDoesNotLockWhileWriting(t *testing.T) {
+	s, err := Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	w := blockingWriter{started: make(chan struct{}), once: &sync.Once{}, release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		_, err := s.Backup(w)
+		done <- err
+	}()
+	defer func() {
+		close(w.release)
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	<-w.started
+	updated := make(chan error)
+	go func() {
+		updated <- s.Update(func(tx Tx) error { return tx.SetConfig("username", "somechannel") })
+	}()
+	select {
+	case err := <-updated:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Update waited for a stalled backup download")
+	}
+}
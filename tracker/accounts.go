@@ -0,0 +1,252 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// accountsBucket keeps the AccountState of every user Twitch stopped knowing by user ID
+const accountsBucket = "accounts"
+
+// States of accounts Twitch no longer returns. Unavailable ones are checked again until they have been gone
+// for accountConfirmHours, closed ones until they come back.
+const (
+	AccountUnavailable = "unavailable"
+	AccountClosed      = "closed"
+	AccountRestored    = "restored"
+)
+
+// Reasons of unfollows that weren't the user's choice, genuine unfollows have none
+const (
+	ReasonBanned             = "banned"
+	ReasonAccountUnavailable = "account_unavailable"
+	ReasonAccountClosed      = "account_closed"
+)
+
+// AccountState what TUT saw of an account Twitch stopped returning. Twitch doesn't tell deleted and suspended
+// accounts apart, both are closed once confirmed.
+type AccountState struct {
+	Status       string `json:"status"`
+	MissingSince string `json:"missingSince"`
+	LastCheck    string `json:"lastCheck"`
+	Checks       int    `json:"checks"`
+	ClosedAt     string `json:"closedAt,omitempty"`
+	RestoredAt   string `json:"restoredAt,omitempty"`
+}
+
+// GetAccount reads the state of an account Twitch stopped returning, nil if it never did
+func GetAccount(tx storage.Tx, uid string) *AccountState {
+	data := tx.Get(accountsBucket, uid)
+	if data == nil {
+		return nil
+	}
+	var state AccountState
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return &state
+}
+
+func putAccount(tx storage.Tx, uid string, state AccountState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return tx.Put(accountsBucket, uid, data)
+}
+
+// markUnavailable records that Twitch didn't return the account of a user, keeping when it first went missing
+func markUnavailable(tx storage.Tx, uid string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	state := GetAccount(tx, uid)
+	if state == nil || state.Status == AccountRestored {
+		state = &AccountState{Status: AccountUnavailable, MissingSince: now}
+	}
+	state.LastCheck = now
+	state.Checks++
+	return putAccount(tx, uid, *state)
+}
+
+// UnfollowReasons finds why every unfollower that didn't leave by choice is gone by user ID. A ban recorded
+// with the unfollow or stored with the roles wins over the account state.
+func UnfollowReasons(tx storage.Tx) map[string]string {
+	reasons := make(map[string]string)
+	roles := AllRoles(tx)
+	tx.ForEachRelation(storage.ListUnfollowers, func(uid string, _ string) error {
+		if reason := storedUnfollowReason(tx, uid, roles[uid]); reason != "" {
+			reasons[uid] = reason
+		}
+		return nil
+	})
+	return reasons
+}
+
+// storedUnfollowReason reads the reason of the last unfollow of a user from their own events, then the account
+// state and roles
+func storedUnfollowReason(tx storage.Tx, uid string, roles *Roles) string {
+	if roles != nil && roles.Banned {
+		return ReasonBanned
+	}
+	reason := ""
+	events := tx.UserEvents(uid)
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == storage.EventUnfollow {
+			reason = events[i].Details["reason"]
+			break
+		}
+	}
+	if reason == ReasonBanned {
+		return reason
+	}
+	if state := GetAccount(tx, uid); state != nil {
+		switch state.Status {
+		case AccountUnavailable:
+			return ReasonAccountUnavailable
+		case AccountClosed:
+			return ReasonAccountClosed
+		case AccountRestored:
+			return ""
+		}
+	}
+	return reason
+}
+
+// unfollowReason tells why a follower is gone, found is whether Twitch still returns their account. Bans are
+// asked from Twitch when the token may read them, else the stored roles tell.
+func (t *Tracker) unfollowReason(uid string, found bool) string {
+	if !found {
+		return ReasonAccountUnavailable
+	}
+	if t.config.OAuth != "" {
+	getBan:
+		result, ban, err := t.twitch.GetBan(t.config.UserID, uid)
+		if result.RateLimited() {
+			result.WaitForReset()
+			goto getBan
+		}
+		if err == nil && result.StatusCode == 200 {
+			if ban != nil {
+				return ReasonBanned
+			}
+			return ""
+		}
+	}
+	var roles *Roles
+	t.store.View(func(tx storage.Tx) error {
+		roles = GetRoles(tx, uid)
+		return nil
+	})
+	if roles != nil && roles.Banned {
+		return ReasonBanned
+	}
+	return ""
+}
+
+// checkAccounts asks Twitch again for every unavailable or closed account. Accounts that are back are
+// restored, unavailable ones gone for accountConfirmHours are closed, both with an account event.
+func (t *Tracker) checkAccounts() error {
+	var uids []string
+	var confirmAfter time.Duration
+	t.store.View(func(tx storage.Tx) error {
+		confirmAfter = time.Duration(storage.IntSetting(tx, "accountConfirmHours")) * time.Hour
+		return tx.ForEach(accountsBucket, func(uid string, data []byte) error {
+			var state AccountState
+			if json.Unmarshal(data, &state) == nil && state.Status != AccountRestored {
+				uids = append(uids, uid)
+			}
+			return nil
+		})
+	})
+	sort.Strings(uids)
+
+	for _, uid := range uids {
+	getAccount:
+		result, err := t.twitch.GetUser(uid)
+		if result.RateLimited() {
+			result.WaitForReset()
+			goto getAccount
+		}
+		if result.StatusCode != 200 {
+			return fmt.Errorf("checkAccounts: Twitch answered %d: %v", result.StatusCode, err)
+		}
+		found := err == nil
+
+		err = t.update(func(tx storage.Tx) error {
+			state := GetAccount(tx, uid)
+			if state == nil {
+				return nil
+			}
+			now := time.Now().UTC()
+			state.LastCheck = now.Format(time.RFC3339)
+			state.Checks++
+
+			e := Event{Type: storage.EventAccount, UserID: uid, At: state.LastCheck, Details: map[string]string{"missingSince": state.MissingSince}}
+			if profile, ok := GetUserProfile(tx, uid); ok {
+				e.Login, e.Displayname = profile["login"], profile["display_name"]
+			}
+			switch {
+			case found:
+				state.Status = AccountRestored
+				state.RestoredAt = state.LastCheck
+				e.Details["status"] = AccountRestored
+				fmt.Printf("[INFO][ACCOUNT / Restored] %s (%s) [%s], missing since %s\n", e.Displayname, e.Login, uid, state.MissingSince)
+				err := putUser(tx, uid, []byte(result.Response["user"]))
+				if err != nil {
+					return err
+				}
+			case state.Status == AccountUnavailable:
+				missingSince, perr := time.Parse(time.RFC3339, state.MissingSince)
+				if perr == nil && now.Sub(missingSince) < confirmAfter {
+					return putAccount(tx, uid, *state)
+				}
+				state.Status = AccountClosed
+				state.ClosedAt = state.LastCheck
+				e.Details["status"] = AccountClosed
+				fmt.Printf("[INFO][ACCOUNT / Closed] %s (%s) [%s], missing since %s\n", e.Displayname, e.Login, uid, state.MissingSince)
+			default:
+				return putAccount(tx, uid, *state)
+			}
+			err := putAccount(tx, uid, *state)
+			if err != nil {
+				return err
+			}
+			return recordEvent(tx, e)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(uids) > 0 {
+		t.invalidateStats()
+	}
+	return nil
+}
+
+// lastKnownName reads the login and display name TUT stored of a user before Twitch stopped returning them
+func (t *Tracker) lastKnownName(uid string) (string, string) {
+	var login, displayname string
+	t.store.View(func(tx storage.Tx) error {
+		if profile, ok := GetUserProfile(tx, uid); ok {
+			login, displayname = profile["login"], profile["display_name"]
+		}
+		return nil
+	})
+	return login, displayname
+}
+
+// storeFetchedUser stores the profile Twitch returned of a user. When Twitch has no such account anymore the
+// last known profile is kept and the accounts job checks it again, other answers store nothing.
+func storeFetchedUser(tx storage.Tx, uid string, found bool, result twitch.Result) error {
+	if found {
+		return putUser(tx, uid, []byte(result.Response["user"]))
+	}
+	if result.StatusCode == 200 {
+		return markUnavailable(tx, uid)
+	}
+	return nil
+}
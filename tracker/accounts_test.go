@@ -0,0 +1,70 @@
+package tracker
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+func TestUnfollowReasons(t *testing.T) {
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	unfollow := func(uid string, reason string) Event {
+		e := Event{Type: storage.EventUnfollow, UserID: uid, At: "2024-05-01T10:00:00Z"}
+		if reason != "" {
+			e.Details = map[string]string{"reason": reason}
+		}
+		return e
+	}
+	err = store.Update(func(tx storage.Tx) error {
+		for _, e := range []Event{
+			unfollow("1", ""),
+			unfollow("2", ReasonBanned),
+			unfollow("3", ReasonAccountUnavailable),
+			unfollow("4", ReasonAccountUnavailable),
+			unfollow("5", ""),
+			// The last unfollow counts, an earlier ban was lifted
+			unfollow("6", ReasonBanned),
+			{Type: storage.EventRefollow, UserID: "6"},
+			unfollow("6", ""),
+			// A refollower isn't an unfollower anymore
+			unfollow("7", ReasonBanned),
+		} {
+			if _, err := tx.AppendEvent(e); err != nil {
+				return err
+			}
+		}
+		for _, uid := range []string{"1", "2", "3", "4", "5", "6"} {
+			if err := tx.PutRelation(storage.ListUnfollowers, uid, "2024-05-01T10:00:00Z"); err != nil {
+				return err
+			}
+		}
+		for uid, status := range map[string]string{"3": AccountClosed, "4": AccountRestored, "8": AccountUnavailable} {
+			if err := putAccount(tx, uid, AccountState{Status: status}); err != nil {
+				return err
+			}
+		}
+		data, _ := json.Marshal(Roles{Banned: true})
+		return tx.Put(rolesBucket, "5", data)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]string
+	store.View(func(tx storage.Tx) error {
+		got = UnfollowReasons(tx)
+		return nil
+	})
+	want := map[string]string{"2": ReasonBanned, "3": ReasonAccountClosed, "5": ReasonBanned}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package tracker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// RunBackups takes a backup every backupInterval minutes and keeps the latest backupKeep of them. RunBackups doesn't return.
+func (t *Tracker) RunBackups() {
+	for {
+		var dir string
+		var interval, keep int
+		t.store.View(func(tx storage.Tx) error {
+			dir = storage.Setting(tx, "backupDir")
+			interval = storage.IntSetting(tx, "backupInterval")
+			keep = storage.IntSetting(tx, "backupKeep")
+			return nil
+		})
+
+		// Pick up where we left off after a restart
+		next := time.Now()
+		if backups := storage.ListBackups(t.store, dir); len(backups) > 0 {
+			if info, err := os.Stat(backups[len(backups)-1]); err == nil {
+				next = info.ModTime().Add(time.Duration(interval) * time.Minute)
+			}
+		}
+
+		if interval > 0 && !time.Now().Before(next) {
+			path := filepath.Join(dir, storage.BackupName(t.store, time.Now()))
+			err := storage.BackupToFile(t.store, path)
+			if err != nil {
+				fmt.Printf("[SYS] Backup to %s failed: %v\n", path, err)
+			} else {
+				fmt.Printf("[SYS] Backed up database to %s\n", path)
+				if keep > 0 {
+					storage.RotateBackups(t.store, dir, keep)
+				}
+			}
+			next = time.Now().Add(time.Duration(interval) * time.Minute)
+		}
+
+		// Settings may change at any time, check them at least every few minutes
+		wait := next.Sub(time.Now())
+		if interval <= 0 || wait > 5*time.Minute {
+			wait = 5 * time.Minute
+		}
+		time.Sleep(wait)
+	}
+}
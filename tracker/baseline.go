@@ -0,0 +1,75 @@
+package tracker
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// baselineKey is the config key holding when the baseline was recorded, empty while the next sync is one
+const baselineKey = "baselineAt"
+
+// InitBaseline decides whether the next sync records a baseline. A database that already tracked
+// a channel before baselines existed counts as baselined, a new one starts with a baseline.
+func InitBaseline(tx storage.Tx) error {
+	if _, ok := tx.Config(baselineKey); ok {
+		return nil
+	}
+	if _, tracked := tx.Config("userID"); tracked {
+		return tx.SetConfig(baselineKey, time.Now().UTC().Format(time.RFC3339))
+	}
+	return tx.SetConfig(baselineKey, "")
+}
+
+// needsBaseline tells whether the next sync should silently record the current followers
+func needsBaseline(tx storage.Tx) bool {
+	at, _ := tx.Config(baselineKey)
+	return at == ""
+}
+
+// recordBaseline marks a completed baseline sync in config and the event history
+func (t *Tracker) recordBaseline() error {
+	return t.update(func(tx storage.Tx) error {
+		now := time.Now().UTC().Format(time.RFC3339)
+		followers := tx.CountRelations(storage.ListFollowers)
+		following := tx.CountRelations(storage.ListFollowing)
+		fmt.Printf("[SYS] Recorded baseline of %d followers and %d following, changes from now on are reported\n", followers, following)
+
+		err := tx.SetConfig(baselineKey, now)
+		if err != nil {
+			return err
+		}
+		// Milestones the new baseline crossed aren't reported either
+		err = t.updateMilestones(tx, false)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx, Event{Type: storage.EventBaseline, UserID: t.config.UserID, Login: t.config.Username, At: now, Details: map[string]string{
+			"followers": strconv.Itoa(followers),
+			"following": strconv.Itoa(following),
+		}})
+	})
+}
+
+// ResetBaseline forgets current followers and following so that the next sync records a new baseline.
+// Unfollowers, users and the event history are kept.
+func ResetBaseline(store storage.Store) error {
+	return store.Update(func(tx storage.Tx) error {
+		for _, list := range []string{storage.ListFollowers, storage.ListFollowing} {
+			var uids []string
+			tx.ForEachRelation(list, func(uid string, _ string) error {
+				uids = append(uids, uid)
+				return nil
+			})
+			for _, uid := range uids {
+				err := tx.DeleteRelation(list, uid)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return tx.SetConfig(baselineKey, "")
+	})
+}
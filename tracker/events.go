@@ -0,0 +1,26 @@
+package tracker
+
+import (
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// recordEvent appends event to the event history
+func recordEvent(tx storage.Tx, e Event) error {
+	_, err := tx.AppendEvent(e)
+	return err
+}
+
+// recordEvents appends events detected just now to the event history
+func recordEvents(tx storage.Tx, events []Event) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range events {
+		e.At = now
+		err := recordEvent(tx, e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// milestonesBucket keeps the milestoneState of every follower count by the count
+const milestonesBucket = "milestones"
+
+// Milestone a follower count and how far the channel is from it. ReachedAt is empty when the channel had
+// reached it before TUT watched it.
+type Milestone struct {
+	Followers int     `json:"followers"`
+	Goal      bool    `json:"goal"`
+	Reached   bool    `json:"reached"`
+	ReachedAt string  `json:"reachedAt"`
+	LostAt    string  `json:"lostAt"`
+	Remaining int     `json:"remaining"`
+	Progress  float64 `json:"progress"`
+}
+
+// Goals the follower count and its progress towards followerGoal and the milestones
+type Goals struct {
+	Followers  int         `json:"followers"`
+	Goal       *Milestone  `json:"goal,omitempty"`
+	Next       *Milestone  `json:"next,omitempty"`
+	Milestones []Milestone `json:"milestones"`
+}
+
+// milestoneState what TUT saw of a follower count
+type milestoneState struct {
+	Reached   bool   `json:"reached"`
+	ReachedAt string `json:"reachedAt,omitempty"`
+	LostAt    string `json:"lostAt,omitempty"`
+}
+
+// milestoneCounts reads the milestones setting and followerGoal, the counts come sorted and once each
+func milestoneCounts(tx storage.Tx) ([]int, int) {
+	goal := storage.IntSetting(tx, "followerGoal")
+	seen := make(map[int]bool)
+	var counts []int
+	for _, s := range append(strings.Split(storage.Setting(tx, "milestones"), ","), strconv.Itoa(goal)) {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		counts = append(counts, n)
+	}
+	sort.Ints(counts)
+	return counts, goal
+}
+
+// getMilestoneState reads what TUT saw of a follower count, false if it never checked it
+func getMilestoneState(tx storage.Tx, count int) (milestoneState, bool) {
+	var state milestoneState
+	data := tx.Get(milestonesBucket, strconv.Itoa(count))
+	if data == nil || json.Unmarshal(data, &state) != nil {
+		return state, false
+	}
+	return state, true
+}
+
+// updateMilestones compares the follower count with every milestone and records when it crossed one, with a
+// milestone event when report is set. Counts checked for the first time are recorded without timestamp.
+func (t *Tracker) updateMilestones(tx storage.Tx, report bool) error {
+	followers := tx.CountRelations(storage.ListFollowers)
+	now := time.Now().UTC().Format(time.RFC3339)
+	counts, goal := milestoneCounts(tx)
+	for _, count := range counts {
+		state, known := getMilestoneState(tx, count)
+		reached := followers >= count
+		if known && state.Reached == reached {
+			continue
+		}
+		state.Reached = reached
+
+		if known && report {
+			direction := "reached"
+			if reached {
+				state.ReachedAt = now
+				fmt.Printf("[INFO][MILESTONE] Reached %d followers, now %d\n", count, followers)
+			} else {
+				direction = "lost"
+				state.LostAt = now
+				fmt.Printf("[INFO][MILESTONE] Dropped below %d followers, now %d\n", count, followers)
+			}
+			err := recordEvent(tx, Event{Type: storage.EventMilestone, UserID: t.config.UserID, Login: t.config.Username, At: now, Details: map[string]string{
+				"milestone": strconv.Itoa(count),
+				"direction": direction,
+				"followers": strconv.Itoa(followers),
+				"goal":      strconv.FormatBool(count == goal),
+			}})
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		err = tx.Put(milestonesBucket, strconv.Itoa(count), data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMilestones reports the milestones the last follower sync crossed
+func (t *Tracker) checkMilestones() {
+	err := t.update(func(tx storage.Tx) error {
+		return t.updateMilestones(tx, true)
+	})
+	if err != nil {
+		fmt.Printf("[SYS] Checking milestones failed: %v\n", err)
+	}
+}
+
+// GetGoals computes the progress of the follower count towards followerGoal and every milestone
+func GetGoals(tx storage.Tx) Goals {
+	followers := tx.CountRelations(storage.ListFollowers)
+	counts, goal := milestoneCounts(tx)
+	goals := Goals{Followers: followers, Milestones: []Milestone{}}
+	for _, count := range counts {
+		state, _ := getMilestoneState(tx, count)
+		m := Milestone{
+			Followers: count,
+			Goal:      count == goal,
+			Reached:   followers >= count,
+			ReachedAt: state.ReachedAt,
+			LostAt:    state.LostAt,
+			Remaining: count - followers,
+			Progress:  math.Min(100, math.Round(1000*float64(followers)/float64(count))/10),
+		}
+		if m.Remaining < 0 {
+			m.Remaining = 0
+		}
+		goals.Milestones = append(goals.Milestones, m)
+		if m.Goal {
+			g := m
+			goals.Goal = &g
+		}
+		if !m.Reached && goals.Next == nil {
+			next := m
+			goals.Next = &next
+		}
+	}
+	return goals
+}
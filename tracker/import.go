@@ -0,0 +1,255 @@
+package tracker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// importRecord one row of a follower or unfollower export
+type importRecord struct {
+	ID              string
+	Login           string
+	Displayname     string
+	ProfileImageURL string
+	FollowedAt      string
+	UnfollowedAt    string
+}
+
+// ImportResult counts what an import did
+type ImportResult struct {
+	Added   int
+	Merged  int
+	Users   int
+	Skipped int
+}
+
+// importFields maps column names and JSON keys, lowercased without "_" and spaces, onto importRecord fields.
+// Besides TUT's own exports this covers Helix follows JSON and most third party CSV exports.
+var importFields = map[string]string{
+	"id": "id", "userid": "id", "fromid": "id",
+	"login": "login", "userlogin": "login", "fromlogin": "login", "username": "login",
+	"displayname": "displayname", "name": "displayname", "fromname": "displayname",
+	"profileimageurl": "profileImageURL", "avatar": "profileImageURL",
+	"followedat": "followedAt", "followed": "followedAt", "followdate": "followedAt",
+	"unfollowedat": "unfollowedAt", "unfollowed": "unfollowedAt", "unfollowdate": "unfollowedAt",
+}
+
+// importTimeLayouts accepted in exports, anything without a zone is taken as UTC
+var importTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+func importField(name string) string {
+	name = strings.ToLower(strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.TrimSpace(name)))
+	return importFields[name]
+}
+
+// set fills a field of r by its importFields name
+func (r *importRecord) set(field string, value string) {
+	value = strings.TrimSpace(value)
+	// TUT's own exports fill in Unknown for users it couldn't fetch
+	if value == "Unknown" && field != "followedAt" && field != "unfollowedAt" {
+		return
+	}
+	switch field {
+	case "id":
+		r.ID = value
+	case "login":
+		r.Login = value
+	case "displayname":
+		r.Displayname = value
+	case "profileImageURL":
+		r.ProfileImageURL = value
+	case "followedAt":
+		r.FollowedAt = importTime(value)
+	case "unfollowedAt":
+		r.UnfollowedAt = importTime(value)
+	}
+}
+
+// importTime normalizes a time of an export into RFC3339 UTC, empty if it can't be read
+func importTime(s string) string {
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// ReadImport reads a CSV or JSON export, JSON is recognized by its leading [ or {
+func ReadImport(r io.Reader) ([]importRecord, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("\xef\xbb\xbf"))
+	if len(data) == 0 {
+		return nil, errors.New("ReadImport: export is empty")
+	}
+	if data[0] == '[' || data[0] == '{' {
+		return readImportJSON(data)
+	}
+	return readImportCSV(data)
+}
+
+// readImportJSON reads an array of users, or an object holding one: the "data" of a Helix response, else
+// its only non-empty array
+func readImportJSON(data []byte) ([]importRecord, error) {
+	var rows []map[string]interface{}
+	if data[0] == '{' {
+		var wrapped map[string]json.RawMessage
+		err := json.Unmarshal(data, &wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := wrapped["data"]; ok {
+			err = json.Unmarshal(v, &rows)
+			if err != nil {
+				return nil, fmt.Errorf("readImportJSON: \"data\" is not an array of users: %v", err)
+			}
+		} else {
+			var keys []string
+			for k, v := range wrapped {
+				var candidate []map[string]interface{}
+				if json.Unmarshal(v, &candidate) == nil && len(candidate) > 0 {
+					keys = append(keys, k)
+					rows = candidate
+				}
+			}
+			if len(keys) != 1 {
+				sort.Strings(keys)
+				return nil, fmt.Errorf("readImportJSON: want one array of users, found %d %q", len(keys), keys)
+			}
+		}
+	} else {
+		err := json.Unmarshal(data, &rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var records []importRecord
+	for _, row := range rows {
+		var r importRecord
+		for k, v := range row {
+			if s, ok := v.(string); ok {
+				r.set(importField(k), s)
+			} else if n, ok := v.(float64); ok {
+				r.set(importField(k), fmt.Sprintf("%.0f", n))
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// readImportCSV reads a CSV export with a header row
+func readImportCSV(data []byte) ([]importRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, len(rows[0]))
+	known := false
+	for i, name := range rows[0] {
+		fields[i] = importField(name)
+		known = known || fields[i] != ""
+	}
+	if !known {
+		return nil, fmt.Errorf("readImportCSV: header %q has no known column, e.g. id, login, followed_at", strings.Join(rows[0], ","))
+	}
+
+	var records []importRecord
+	for _, row := range rows[1:] {
+		var r importRecord
+		for i, value := range row {
+			if i < len(fields) {
+				r.set(fields[i], value)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// ImportRecords merges an export into a relationship list without recording events, as nothing happened
+// just now. Known entries keep their time, except a later unfollow which replaces an earlier one.
+func ImportRecords(tx storage.Tx, list string, records []importRecord) ImportResult {
+	var result ImportResult
+	for _, r := range records {
+		// Exports without IDs are matched by login against users TUT already knows
+		if r.ID == "" && r.Login != "" {
+			r.ID = tx.LookupLogin(r.Login)
+		}
+		at := r.FollowedAt
+		if list == storage.ListUnfollowers {
+			at = r.UnfollowedAt
+		}
+		if r.ID == "" || at == "" {
+			result.Skipped++
+			continue
+		}
+
+		added, err := importRelation(tx, list, r.ID, at)
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		if added {
+			result.Added++
+		} else {
+			result.Merged++
+		}
+
+		// A refollower carries their previous unfollow
+		if list == storage.ListFollowers && r.UnfollowedAt != "" {
+			importRelation(tx, storage.ListUnfollowers, r.ID, r.UnfollowedAt)
+		}
+
+		if _, ok := tx.User(r.ID); !ok && r.Login != "" {
+			if importUser(tx, r) == nil {
+				result.Users++
+			}
+		}
+	}
+	return result
+}
+
+// importRelation adds uid to list, reports whether it was not there before
+func importRelation(tx storage.Tx, list string, uid string, at string) (bool, error) {
+	existing := tx.Relation(list, uid)
+	if existing == "" {
+		return true, tx.PutRelation(list, uid, at)
+	}
+	if list == storage.ListUnfollowers && at > existing {
+		return false, tx.PutRelation(list, uid, at)
+	}
+	return false, nil
+}
+
+// importUser stores the profile an export carries, shaped like Helix user JSON.
+// Lacking created_at, it is replaced by the full profile once updateUsers gets to it.
+func importUser(tx storage.Tx, r importRecord) error {
+	helix, err := json.Marshal(map[string]string{
+		"id":                r.ID,
+		"login":             r.Login,
+		"display_name":      r.Displayname,
+		"profile_image_url": r.ProfileImageURL,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.PutUser(r.ID, storage.UserRecord{V: storage.UserVersion, User: helix})
+}
@@ -0,0 +1,100 @@
+package tracker
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+func TestReadImport(t *testing.T) {
+	alice := importRecord{ID: "1", Login: "alice", Displayname: "Alice", FollowedAt: "2024-05-01T10:00:00Z"}
+	bob := importRecord{ID: "2", Login: "bob", FollowedAt: "2024-05-02T00:00:00Z", UnfollowedAt: "2024-06-01T12:30:00Z"}
+
+	for _, test := range []struct {
+		name   string
+		export string
+		want   []importRecord
+	}{
+		{"TUT CSV", "\xef\xbb\xbfUser ID,Login,Display Name,Followed At,Unfollowed At\n1,alice,Alice,2024-05-01T10:00:00Z,\n2,bob,Unknown,2024-05-02,2024-06-01 12:30:00\n", []importRecord{alice, bob}},
+		{"JSON array", `[{"id":"1","login":"alice","display_name":"Alice","followed_at":"2024-05-01T10:00:00Z"},{"user_id":2,"user_login":"bob","followed_at":"2024-05-02","unfollowed_at":"2024-06-01T12:30:00Z"}]`, []importRecord{alice, bob}},
+		{"Helix response", `{"total":1,"data":[{"from_id":"1","from_login":"alice","from_name":"Alice","followed_at":"2024-05-01T10:00:00Z"}],"pagination":{}}`, []importRecord{alice}},
+		// Other arrays may come first in the object, empty ones must not win over the users
+		{"data beside empty arrays", `{"errors":[],"warnings":null,"data":[{"id":"1","login":"alice","display_name":"Alice","followed_at":"2024-05-01T10:00:00Z"}]}`, []importRecord{alice}},
+		{"only array", `{"errors":[],"followers":[{"id":"1","login":"alice","display_name":"Alice","followed_at":"2024-05-01T10:00:00Z"}]}`, []importRecord{alice}},
+	} {
+		// Map order is random, a wrong pick shows up within a few runs
+		for i := 0; i < 20; i++ {
+			got, err := ReadImport(strings.NewReader(test.export))
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("%s: got %+v, want %+v", test.name, got, test.want)
+			}
+		}
+	}
+
+	for _, export := range []string{"", `{"total":0}`, `{"a":[{"id":"1"}],"b":[{"id":"2"}]}`, `{"data":{"id":"1"}}`, "nick,color\nalice,red\n"} {
+		if records, err := ReadImport(strings.NewReader(export)); err == nil {
+			t.Errorf("%q: got %+v, want an error", export, records)
+		}
+	}
+}
+
+func TestImportRecords(t *testing.T) {
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	records := []importRecord{
+		{ID: "1", Login: "alice", Displayname: "Alice", FollowedAt: "2024-05-01T10:00:00Z"},
+		{ID: "2", Login: "bob", FollowedAt: "2024-05-02T00:00:00Z", UnfollowedAt: "2024-04-01T00:00:00Z"},
+		{Login: "carol", FollowedAt: "2024-05-03T00:00:00Z"},
+		{ID: "4", Login: "dave"},
+	}
+	var result ImportResult
+	err = store.Update(func(tx storage.Tx) error {
+		// Known followers keep their time, carol is matched by login
+		if err := tx.PutRelation(storage.ListFollowers, "1", "2023-01-01T00:00:00Z"); err != nil {
+			return err
+		}
+		if err := tx.PutUser("3", storage.UserRecord{V: storage.UserVersion, User: []byte(`{"id":"3","login":"carol","display_name":"Carol"}`)}); err != nil {
+			return err
+		}
+		result = ImportRecords(tx, storage.ListFollowers, records)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportResult{Added: 2, Merged: 1, Users: 2, Skipped: 1}); result != want {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+
+	store.View(func(tx storage.Tx) error {
+		for _, test := range []struct{ list, uid, want string }{
+			{storage.ListFollowers, "1", "2023-01-01T00:00:00Z"},
+			{storage.ListFollowers, "2", "2024-05-02T00:00:00Z"},
+			{storage.ListFollowers, "3", "2024-05-03T00:00:00Z"},
+			{storage.ListFollowers, "4", ""},
+			{storage.ListUnfollowers, "2", "2024-04-01T00:00:00Z"},
+		} {
+			if got := tx.Relation(test.list, test.uid); got != test.want {
+				t.Errorf("%s %s at %q, want %q", test.list, test.uid, got, test.want)
+			}
+		}
+		if profile, ok := GetUserProfile(tx, "2"); !ok || profile["login"] != "bob" {
+			t.Errorf("imported profile of bob %v", profile)
+		}
+		// Nothing happened just now, an import records no events
+		return tx.ForEachEvent(func(e Event) error {
+			t.Errorf("import recorded %+v", e)
+			return nil
+		})
+	})
+}
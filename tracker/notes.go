@@ -0,0 +1,233 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// notesBucket keeps the notesRecord of every user with notes or labels by user ID
+const notesBucket = "notes"
+
+// Limits of notes and labels, they are meant for moderators, not for documents
+const (
+	maxNote  = 2000
+	maxLabel = 64
+)
+
+// Note free text about a user, with who wrote it when and who last changed it
+type Note struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Label a free text label of a user such as "sub since 2019", with who added it when
+type Label struct {
+	Name   string `json:"name"`
+	Author string `json:"author"`
+	At     string `json:"at"`
+}
+
+// UserNotes the notes and labels of a user
+type UserNotes struct {
+	ID          string  `json:"id"`
+	Login       string  `json:"login"`
+	Displayname string  `json:"displayname"`
+	Notes       []Note  `json:"notes"`
+	Labels      []Label `json:"labels"`
+}
+
+// notesRecord what notesBucket keeps of a user, NextID keeps note IDs unique while the user has any
+type notesRecord struct {
+	Notes  []Note  `json:"notes,omitempty"`
+	Labels []Label `json:"labels,omitempty"`
+	NextID int     `json:"nextID"`
+}
+
+func getNotesRecord(tx storage.Tx, uid string) notesRecord {
+	var record notesRecord
+	json.Unmarshal(tx.Get(notesBucket, uid), &record)
+	return record
+}
+
+func putNotesRecord(tx storage.Tx, uid string, record notesRecord) error {
+	if len(record.Notes) == 0 && len(record.Labels) == 0 {
+		return tx.Delete(notesBucket, uid)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return tx.Put(notesBucket, uid, data)
+}
+
+// userNotes fills in the profile of a user to their notes and labels
+func userNotes(tx storage.Tx, uid string, record notesRecord) UserNotes {
+	n := UserNotes{ID: uid, Notes: record.Notes, Labels: record.Labels}
+	if profile, ok := GetUserProfile(tx, uid); ok {
+		n.Login = profile["login"]
+		n.Displayname = profile["display_name"]
+	}
+	if n.Notes == nil {
+		n.Notes = []Note{}
+	}
+	if n.Labels == nil {
+		n.Labels = []Label{}
+	}
+	return n
+}
+
+// GetNotes reads the notes and labels of a user, none if there are none
+func GetNotes(tx storage.Tx, uid string) UserNotes {
+	return userNotes(tx, uid, getNotesRecord(tx, uid))
+}
+
+// AllNotes reads the notes and labels of every user that has some by user ID, without profiles
+func AllNotes(tx storage.Tx) map[string]UserNotes {
+	all := make(map[string]UserNotes)
+	tx.ForEach(notesBucket, func(uid string, data []byte) error {
+		var record notesRecord
+		if json.Unmarshal(data, &record) == nil {
+			all[uid] = UserNotes{ID: uid, Notes: record.Notes, Labels: record.Labels}
+		}
+		return nil
+	})
+	return all
+}
+
+// SearchNotes finds users with label, compared case insensitively, and users whose notes or labels contain q.
+// Either may be empty, both empty finds every user with notes or labels.
+func SearchNotes(tx storage.Tx, label string, q string) []UserNotes {
+	label = strings.TrimSpace(label)
+	q = strings.ToLower(strings.TrimSpace(q))
+	found := []UserNotes{}
+	tx.ForEach(notesBucket, func(uid string, data []byte) error {
+		var record notesRecord
+		if json.Unmarshal(data, &record) != nil {
+			return nil
+		}
+		if label != "" && labelIndex(record.Labels, label) < 0 {
+			return nil
+		}
+		if q != "" && !notesContain(record, q) {
+			return nil
+		}
+		found = append(found, userNotes(tx, uid, record))
+		return nil
+	})
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Login < found[j].Login
+	})
+	return found
+}
+
+func notesContain(record notesRecord, q string) bool {
+	for _, n := range record.Notes {
+		if strings.Contains(strings.ToLower(n.Text), q) {
+			return true
+		}
+	}
+	for _, l := range record.Labels {
+		if strings.Contains(strings.ToLower(l.Name), q) {
+			return true
+		}
+	}
+	return false
+}
+
+func validNote(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("empty note")
+	}
+	if utf8.RuneCountInString(text) > maxNote {
+		return errors.New("note longer than 2000 characters")
+	}
+	return nil
+}
+
+// AddNote adds a note to a user, author is who wrote it
+func AddNote(tx storage.Tx, uid string, text string, author string) (Note, error) {
+	text = strings.TrimSpace(text)
+	if err := validNote(text); err != nil {
+		return Note{}, errors.New("AddNote: " + err.Error())
+	}
+	record := getNotesRecord(tx, uid)
+	record.NextID++
+	note := Note{ID: record.NextID, Text: text, Author: author, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	record.Notes = append(record.Notes, note)
+	return note, putNotesRecord(tx, uid, record)
+}
+
+// EditNote replaces the text of a note, author is who changed it. False if the user has no such note.
+func EditNote(tx storage.Tx, uid string, id int, text string, author string) (Note, bool, error) {
+	text = strings.TrimSpace(text)
+	if err := validNote(text); err != nil {
+		return Note{}, false, errors.New("EditNote: " + err.Error())
+	}
+	record := getNotesRecord(tx, uid)
+	for i := range record.Notes {
+		if record.Notes[i].ID == id {
+			record.Notes[i].Text = text
+			record.Notes[i].UpdatedBy = author
+			record.Notes[i].UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+			return record.Notes[i], true, putNotesRecord(tx, uid, record)
+		}
+	}
+	return Note{}, false, nil
+}
+
+// DeleteNote deletes a note of a user, false if the user has no such note
+func DeleteNote(tx storage.Tx, uid string, id int) (bool, error) {
+	record := getNotesRecord(tx, uid)
+	for i := range record.Notes {
+		if record.Notes[i].ID == id {
+			record.Notes = append(record.Notes[:i], record.Notes[i+1:]...)
+			return true, putNotesRecord(tx, uid, record)
+		}
+	}
+	return false, nil
+}
+
+// labelIndex finds a label by name, compared case insensitively, -1 if there is none
+func labelIndex(labels []Label, name string) int {
+	for i, l := range labels {
+		if strings.EqualFold(l.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddLabel labels a user, author is who added it. Adding a label twice is no error and keeps the first author.
+func AddLabel(tx storage.Tx, uid string, name string, author string) error {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > maxLabel {
+		return errors.New("AddLabel: labels are 1 to 64 characters")
+	}
+	record := getNotesRecord(tx, uid)
+	if labelIndex(record.Labels, name) >= 0 {
+		return nil
+	}
+	record.Labels = append(record.Labels, Label{Name: name, Author: author, At: time.Now().UTC().Format(time.RFC3339)})
+	return putNotesRecord(tx, uid, record)
+}
+
+// RemoveLabel removes a label of a user, false if the user doesn't have it
+func RemoveLabel(tx storage.Tx, uid string, name string) (bool, error) {
+	record := getNotesRecord(tx, uid)
+	i := labelIndex(record.Labels, strings.Join(strings.Fields(name), " "))
+	if i < 0 {
+		return false, nil
+	}
+	record.Labels = append(record.Labels[:i], record.Labels[i+1:]...)
+	return true, putNotesRecord(tx, uid, record)
+}
@@ -0,0 +1,297 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// rolesBucket keeps the Roles of every user with one by user ID, roleSyncBucket the RoleSync of every kind
+const (
+	rolesBucket    = "roles"
+	roleSyncBucket = "roleSync"
+)
+
+// Kinds of roles TUT reads from Twitch
+const (
+	RoleSubscriber = "subscriber"
+	RoleVIP        = "vip"
+	RoleModerator  = "moderator"
+	RoleBanned     = "banned"
+)
+
+// roleScopes the OAuth scope Twitch wants for each kind of role
+var roleScopes = map[string]string{
+	RoleSubscriber: "channel:read:subscriptions",
+	RoleVIP:        "channel:read:vips",
+	RoleModerator:  "moderation:read",
+	RoleBanned:     "moderation:read",
+}
+
+// Roles what a user is in the tracked channel, SubTier is 1, 2 or 3 for subscribers. BanExpiresAt is set for
+// timeouts, empty for permanent bans.
+type Roles struct {
+	SubTier      string `json:"subTier,omitempty"`
+	SubGift      bool   `json:"subGift,omitempty"`
+	VIP          bool   `json:"vip,omitempty"`
+	Moderator    bool   `json:"moderator,omitempty"`
+	Banned       bool   `json:"banned,omitempty"`
+	BanExpiresAt string `json:"banExpiresAt,omitempty"`
+	BanReason    string `json:"banReason,omitempty"`
+}
+
+// RoleSync when TUT last read a kind of role and how that went. Error tells which scope to authorize when
+// Twitch refused.
+type RoleSync struct {
+	Kind     string `json:"kind"`
+	Scope    string `json:"scope"`
+	LastSync string `json:"lastSync"`
+	Users    int    `json:"users"`
+	Error    string `json:"error,omitempty"`
+}
+
+// any tells whether r holds any role
+func (r Roles) any() bool {
+	return r.SubTier != "" || r.VIP || r.Moderator || r.Banned
+}
+
+// String lists the roles for people, e.g. "tier 3 sub, VIP"
+func (r Roles) String() string {
+	var roles []string
+	if r.SubTier != "" {
+		sub := "tier " + r.SubTier + " sub"
+		if r.SubGift {
+			sub += " (gift)"
+		}
+		roles = append(roles, sub)
+	}
+	if r.VIP {
+		roles = append(roles, "VIP")
+	}
+	if r.Moderator {
+		roles = append(roles, "moderator")
+	}
+	if r.Banned && r.BanExpiresAt != "" {
+		roles = append(roles, "timed out until "+r.BanExpiresAt)
+	} else if r.Banned {
+		roles = append(roles, "banned")
+	}
+	return strings.Join(roles, ", ")
+}
+
+// GetRoles reads the roles of a user, nil if they have none
+func GetRoles(tx storage.Tx, uid string) *Roles {
+	data := tx.Get(rolesBucket, uid)
+	if data == nil {
+		return nil
+	}
+	var roles Roles
+	if json.Unmarshal(data, &roles) != nil {
+		return nil
+	}
+	return &roles
+}
+
+// AllRoles reads the roles of every user that has any by user ID
+func AllRoles(tx storage.Tx) map[string]*Roles {
+	all := make(map[string]*Roles)
+	tx.ForEach(rolesBucket, func(uid string, data []byte) error {
+		var roles Roles
+		if json.Unmarshal(data, &roles) == nil {
+			all[uid] = &roles
+		}
+		return nil
+	})
+	return all
+}
+
+// GetRoleSyncs reads how the last read of every kind of role went
+func GetRoleSyncs(tx storage.Tx) []RoleSync {
+	syncs := []RoleSync{}
+	for _, kind := range []string{RoleSubscriber, RoleVIP, RoleModerator, RoleBanned} {
+		sync, ok := getRoleSync(tx, kind)
+		if !ok {
+			sync = RoleSync{Kind: kind, Scope: roleScopes[kind]}
+		}
+		syncs = append(syncs, sync)
+	}
+	return syncs
+}
+
+// readRoles reads every page of a kind of role from Twitch into roles by user ID
+func (t *Tracker) readRoles(kind string, roles map[string]*Roles) error {
+	role := func(uid string) *Roles {
+		if roles[uid] == nil {
+			roles[uid] = &Roles{}
+		}
+		return roles[uid]
+	}
+
+	pagination := ""
+	for {
+		var result twitch.Result
+		var err error
+		switch kind {
+		case RoleSubscriber:
+			var subs []twitch.Subscription
+			result, subs, err = t.twitch.GetSubscriptions(t.config.UserID, pagination)
+			for _, s := range subs {
+				r := role(s.UserID)
+				r.SubTier = strings.TrimSuffix(s.Tier, "000")
+				r.SubGift = s.Gift
+			}
+		case RoleVIP, RoleModerator:
+			var ids []string
+			if kind == RoleVIP {
+				result, ids, err = t.twitch.GetVIPs(t.config.UserID, pagination)
+			} else {
+				result, ids, err = t.twitch.GetModerators(t.config.UserID, pagination)
+			}
+			for _, id := range ids {
+				if kind == RoleVIP {
+					role(id).VIP = true
+				} else {
+					role(id).Moderator = true
+				}
+			}
+		case RoleBanned:
+			var bans []twitch.Ban
+			result, bans, err = t.twitch.GetBannedUsers(t.config.UserID, pagination)
+			for _, b := range bans {
+				r := role(b.UserID)
+				r.Banned = true
+				r.BanExpiresAt = b.ExpiresAt
+				r.BanReason = b.Reason
+			}
+		}
+		if result.RateLimited() {
+			result.WaitForReset()
+			continue
+		}
+		if result.StatusCode == 401 || result.StatusCode == 403 {
+			return fmt.Errorf("readRoles: Twitch answered %d, authorize %s with the token of the channel", result.StatusCode, roleScopes[kind])
+		}
+		if err != nil {
+			return err
+		}
+		pagination = result.Response["next"]
+		if pagination == "" {
+			return nil
+		}
+	}
+}
+
+// updateRoles reads the kinds of roles listed in the roles setting and replaces the stored roles of every kind
+// Twitch answered for. Kinds whose scope the token lacks keep what was stored before. Without OAuth token
+// there is nothing to read.
+func (t *Tracker) updateRoles() error {
+	var kinds []string
+	t.store.View(func(tx storage.Tx) error {
+		for _, kind := range strings.Split(storage.Setting(tx, "roles"), ",") {
+			if kind = strings.TrimSpace(kind); roleScopes[kind] != "" {
+				kinds = append(kinds, kind)
+			}
+		}
+		return nil
+	})
+	if t.config.OAuth == "" || len(kinds) == 0 {
+		return nil
+	}
+
+	read := make(map[string]map[string]*Roles)
+	var failed []string
+	now := time.Now().UTC().Format(time.RFC3339)
+	syncs := make(map[string]RoleSync)
+	for _, kind := range kinds {
+		roles := make(map[string]*Roles)
+		err := t.readRoles(kind, roles)
+		sync := RoleSync{Kind: kind, Scope: roleScopes[kind], LastSync: now, Users: len(roles)}
+		if err != nil {
+			sync.Error = err.Error()
+			failed = append(failed, kind)
+		} else {
+			read[kind] = roles
+		}
+		syncs[kind] = sync
+	}
+
+	err := t.store.Update(func(tx storage.Tx) error {
+		for kind, sync := range syncs {
+			if sync.Error != "" {
+				// Keep when the last successful read was
+				old, _ := getRoleSync(tx, kind)
+				sync.LastSync, sync.Users = old.LastSync, old.Users
+			}
+			data, err := json.Marshal(sync)
+			if err != nil {
+				return err
+			}
+			err = tx.Put(roleSyncBucket, kind, data)
+			if err != nil {
+				return err
+			}
+		}
+
+		all := AllRoles(tx)
+		for kind, roles := range read {
+			for uid := range roles {
+				if all[uid] == nil {
+					all[uid] = &Roles{}
+				}
+			}
+			for uid, r := range all {
+				fetched := roles[uid]
+				if fetched == nil {
+					fetched = &Roles{}
+				}
+				switch kind {
+				case RoleSubscriber:
+					r.SubTier, r.SubGift = fetched.SubTier, fetched.SubGift
+				case RoleVIP:
+					r.VIP = fetched.VIP
+				case RoleModerator:
+					r.Moderator = fetched.Moderator
+				case RoleBanned:
+					r.Banned, r.BanExpiresAt, r.BanReason = fetched.Banned, fetched.BanExpiresAt, fetched.BanReason
+				}
+			}
+		}
+		for uid, r := range all {
+			if !r.any() {
+				err := tx.Delete(rolesBucket, uid)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			err = tx.Put(rolesBucket, uid, data)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("updateRoles: could not read %s, see /status", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func getRoleSync(tx storage.Tx, kind string) (RoleSync, bool) {
+	var sync RoleSync
+	data := tx.Get(roleSyncBucket, kind)
+	return sync, data != nil && json.Unmarshal(data, &sync) == nil
+}
@@ -0,0 +1,303 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/robfig/cron/v3"
+)
+
+// job something the scheduler runs, its schedule is read from a setting
+type job struct {
+	name    string
+	setting string
+	run     func() error
+}
+
+// JobStatus when a job ran, how that went and when it runs next
+type JobStatus struct {
+	Name        string `json:"name"`
+	Schedule    string `json:"schedule"`
+	Running     bool   `json:"running"`
+	LastStart   string `json:"lastStart"`
+	LastEnd     string `json:"lastEnd"`
+	LastResult  string `json:"lastResult"`
+	LastError   string `json:"lastError,omitempty"`
+	LastSuccess string `json:"lastSuccess"`
+	NextRun     string `json:"nextRun"`
+}
+
+// defaultJobs syncs followers with streams, stats, alerts and milestones, following, profiles, roles, and
+// checks accounts Twitch stopped returning
+func (t *Tracker) defaultJobs() []job {
+	return []job{
+		{"followers", "followersSchedule", func() error {
+			err := t.Sync(storage.ListFollowers)
+			t.updateStreams()
+			t.invalidateStats()
+			t.alertSuspicious()
+			if err == nil {
+				t.checkMilestones()
+			}
+			return err
+		}},
+		{"following", "followingSchedule", func() error {
+			return t.Sync(storage.ListFollowing)
+		}},
+		{"profiles", "profilesSchedule", func() error {
+			return t.updateUsers()
+		}},
+		{"roles", "rolesSchedule", func() error {
+			err := t.updateRoles()
+			// Stats tell banned unfollowers apart by their roles, kinds that were read may have changed that
+			t.invalidateStats()
+			return err
+		}},
+		{"accounts", "accountsSchedule", func() error {
+			return t.checkAccounts()
+		}},
+	}
+}
+
+// jobSchedule reads the schedule of a job, an empty followers or following schedule means every updateInterval minutes
+func jobSchedule(tx storage.Tx, j job, updateInterval int) string {
+	spec := strings.TrimSpace(storage.Setting(tx, j.setting))
+	if spec == "" {
+		spec = fmt.Sprintf("@every %dm", updateInterval)
+	}
+	return spec
+}
+
+// parseSchedule parses a cron expression ("0 * * * *") or descriptor ("@hourly", "@every 30m") in loc
+func parseSchedule(spec string, loc *time.Location) (cron.Schedule, error) {
+	if !strings.HasPrefix(spec, "TZ=") && !strings.HasPrefix(spec, "CRON_TZ=") {
+		spec = "CRON_TZ=" + loc.String() + " " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+// parseQuietHours parses "HH:MM-HH:MM" into minutes of the day, the range may wrap around midnight
+func parseQuietHours(s string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("parseQuietHours: %q is not HH:MM-HH:MM", s)
+	}
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return 0, 0, fmt.Errorf("parseQuietHours: %q is not HH:MM-HH:MM", s)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return minutes[0], minutes[1], nil
+}
+
+// inQuietHours tells whether t falls between start and end minutes of its day
+func inQuietHours(t time.Time, start int, end int) bool {
+	m := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// nextRun finds the next run of a schedule after t that is not within quiet hours of loc, plus up to jitter
+func nextRun(schedule cron.Schedule, t time.Time, loc *time.Location, quiet string, jitter time.Duration) time.Time {
+	next := schedule.Next(t)
+	if start, end, err := parseQuietHours(quiet); err == nil && start != end {
+		// Skip to the end of quiet hours, a rare schedule may hit the quiet hours of a later day again
+		for i := 0; i < 366 && inQuietHours(next.In(loc), start, end); i++ {
+			local := next.In(loc)
+			quietEnd := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+			if !quietEnd.After(local) {
+				quietEnd = quietEnd.AddDate(0, 0, 1)
+			}
+			next = schedule.Next(quietEnd.Add(-time.Second))
+			if _, every := schedule.(cron.ConstantDelaySchedule); every {
+				// "@every" runs have no fixed times, catch up as soon as quiet hours end
+				next = quietEnd
+			}
+		}
+	}
+	if jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	return next
+}
+
+// scheduleConfig schedules, quiet hours and jitter of every job
+type scheduleConfig struct {
+	specs     map[string]string
+	schedules map[string]cron.Schedule
+	invalid   map[string]error
+	loc       *time.Location
+	quiet     string
+	jitter    time.Duration
+}
+
+// scheduleSettings reads everything the scheduler needs, settings may change while TUT runs
+func (t *Tracker) scheduleSettings() scheduleConfig {
+	sc := scheduleConfig{specs: make(map[string]string), schedules: make(map[string]cron.Schedule), invalid: make(map[string]error)}
+	interval := t.config.UpdateInterval
+	t.store.View(func(tx storage.Tx) error {
+		loc, err := time.LoadLocation(storage.Setting(tx, "scheduleTimezone"))
+		if err != nil {
+			loc = time.Local
+		}
+		sc.loc = loc
+		for _, j := range t.jobs {
+			spec := jobSchedule(tx, j, interval)
+			schedule, err := parseSchedule(spec, loc)
+			if err != nil {
+				sc.invalid[j.name] = err
+				schedule = cron.Every(time.Duration(interval) * time.Minute)
+			}
+			sc.specs[j.name] = spec
+			sc.schedules[j.name] = schedule
+		}
+		sc.quiet = storage.Setting(tx, "quietHours")
+		sc.jitter = time.Duration(storage.IntSetting(tx, "scheduleJitter")) * time.Second
+		return nil
+	})
+	return sc
+}
+
+func (t *Tracker) setJobStatus(name string, update func(s *JobStatus)) {
+	t.scheduleMu.Lock()
+	defer t.scheduleMu.Unlock()
+	s, ok := t.jobStatus[name]
+	if !ok {
+		s = &JobStatus{Name: name}
+		t.jobStatus[name] = s
+	}
+	update(s)
+}
+
+// Jobs lists the status of every job in the order they are defined
+func (t *Tracker) Jobs() []JobStatus {
+	t.scheduleMu.Lock()
+	defer t.scheduleMu.Unlock()
+	status := []JobStatus{}
+	for _, j := range t.jobs {
+		if s, ok := t.jobStatus[j.name]; ok {
+			status = append(status, *s)
+		} else {
+			status = append(status, JobStatus{Name: j.name})
+		}
+	}
+	return status
+}
+
+// RequestSync asks the scheduler to run a job now, ignoring quiet hours
+func (t *Tracker) RequestSync(name string) error {
+	for _, j := range t.jobs {
+		if j.name == name {
+			select {
+			case t.syncRequests <- name:
+			default:
+				// Already requested
+			}
+			return nil
+		}
+	}
+	return errors.New("RequestSync: unknown job " + strconv.Quote(name))
+}
+
+// jobResult how a run of a job ended
+type jobResult struct {
+	name string
+	err  error
+}
+
+// Run runs jobs when they are due or requested, every job runs once at start up. Different jobs run at the
+// same time, e.g. the follower and following syncs, but a job never overlaps itself. Run doesn't return.
+func (t *Tracker) Run() {
+	next := make(map[string]time.Time)
+	specs := make(map[string]string)
+	running := make(map[string]bool)
+	requested := make(map[string]bool)
+	done := make(chan jobResult)
+	now := time.Now()
+	for _, j := range t.jobs {
+		next[j.name] = now
+	}
+
+	for {
+		sc := t.scheduleSettings()
+		for _, j := range t.jobs {
+			if specs[j.name] != sc.specs[j.name] {
+				if err, ok := sc.invalid[j.name]; ok {
+					fmt.Printf("[SYS] Invalid %s %q, using every %d minutes: %v\n", j.setting, sc.specs[j.name], t.config.UpdateInterval, err)
+				}
+				// A changed schedule applies right away
+				if specs[j.name] != "" {
+					next[j.name] = nextRun(sc.schedules[j.name], time.Now(), sc.loc, sc.quiet, sc.jitter)
+				}
+				specs[j.name] = sc.specs[j.name]
+			}
+			name := j.name
+			t.setJobStatus(name, func(s *JobStatus) {
+				s.Schedule = sc.specs[name]
+				s.NextRun = next[name].UTC().Format(time.RFC3339)
+			})
+		}
+
+		// Start every due job that isn't running yet
+		for _, j := range t.jobs {
+			if running[j.name] || time.Now().Before(next[j.name]) {
+				continue
+			}
+			running[j.name] = true
+			t.setJobStatus(j.name, func(s *JobStatus) {
+				s.Running = true
+				s.LastStart = time.Now().UTC().Format(time.RFC3339)
+			})
+			go func(j job) {
+				done <- jobResult{j.name, j.run()}
+			}(j)
+		}
+
+		// Wait for the next job, a finished job, a request, or a while to pick up changed settings
+		first := time.Now().Add(time.Minute)
+		for _, j := range t.jobs {
+			if !running[j.name] && next[j.name].Before(first) {
+				first = next[j.name]
+			}
+		}
+		select {
+		case name := <-t.syncRequests:
+			fmt.Printf("[SYS] Sync of %s requested\n", name)
+			next[name] = time.Now()
+			// A job requested while it runs runs again once it is done
+			requested[name] = running[name]
+		case r := <-done:
+			finished := time.Now()
+			running[r.name] = false
+			next[r.name] = nextRun(sc.schedules[r.name], finished, sc.loc, sc.quiet, sc.jitter)
+			if requested[r.name] {
+				next[r.name] = finished
+				requested[r.name] = false
+			}
+			t.setJobStatus(r.name, func(s *JobStatus) {
+				s.Running = false
+				s.LastEnd = finished.UTC().Format(time.RFC3339)
+				s.LastResult = "ok"
+				s.LastError = ""
+				if r.err != nil {
+					fmt.Printf("[SYS] %s failed: %v\n", r.name, r.err)
+					s.LastResult = "failed"
+					s.LastError = r.err.Error()
+				} else {
+					s.LastSuccess = s.LastEnd
+				}
+			})
+		case <-time.After(first.Sub(time.Now())):
+		}
+	}
+}
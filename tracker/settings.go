@@ -0,0 +1,34 @@
+package tracker
+
+import (
+	"github.com/devinjdawson/tut/storage"
+)
+
+func init() {
+	storage.SetDefaults(map[string]string{
+		"suspiciousAlerts":    "false",
+		"suspiciousScore":     "50",
+		"burstWindow":         "10", // minutes
+		"burstSize":           "20",
+		"newAccountDays":      "7",
+		"followUnfollowHours": "24",
+		"statsBucket":         "day",
+		"statsTimezone":       "UTC",
+		"streamAroundHours":   "3", // hours before or after a stream session whose activity counts around it
+		"backupDir":           "backups",
+		"backupInterval":      "1440", // minutes, 0 disables automatic backups
+		"backupKeep":          "7",
+		"followersSchedule":   "", // cron expression or @every, empty is every updateInterval minutes
+		"followingSchedule":   "",
+		"profilesSchedule":    "@every 1m",
+		"rolesSchedule":       "@every 30m",
+		"roles":               "subscriber,vip,moderator,banned",
+		"accountsSchedule":    "@every 6h",
+		"accountConfirmHours": "72",
+		"quietHours":          "",  // HH:MM-HH:MM without scheduled syncs
+		"scheduleJitter":      "0", // seconds
+		"scheduleTimezone":    "Local",
+		"milestones":          "100,250,500,1000,2500,5000,10000,25000,50000,100000",
+		"followerGoal":        "0", // 0 for none
+	})
+}
@@ -0,0 +1,205 @@
+package tracker
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// Stats churn and growth numbers of the tracked channel. Unfollows counts every unfollower, of which
+// UnfollowsBanned were banned and UnfollowsClosed had their accounts deleted or suspended, GenuineUnfollows
+// are the rest.
+type Stats struct {
+	Bucket              string       `json:"bucket"`
+	Timezone            string       `json:"timezone"`
+	Followers           int          `json:"followers"`
+	Follows             int          `json:"follows"`
+	Unfollows           int          `json:"unfollows"`
+	UnfollowsBanned     int          `json:"unfollowsBanned"`
+	UnfollowsClosed     int          `json:"unfollowsClosed"`
+	GenuineUnfollows    int          `json:"genuineUnfollows"`
+	Refollows           int          `json:"refollows"`
+	UnfollowRate        float64      `json:"unfollowRate"`
+	GenuineUnfollowRate float64      `json:"genuineUnfollowRate"`
+	RefollowRatio       float64      `json:"refollowRatio"`
+	AvgFollowDuration   float64      `json:"avgFollowDurationSeconds"`
+	Growth              []GrowthStat `json:"growth"`
+	ChurnHours          []ChurnHour  `json:"churnHours"`
+	Streams             []StreamStat `json:"streams"`
+	ComputedAt          string       `json:"computedAt"`
+}
+
+// GrowthStat follows, unfollows and net change of one day, week or month. Removed counts the unfollows of
+// banned users and closed accounts.
+type GrowthStat struct {
+	Start     string `json:"start"`
+	Follows   int    `json:"follows"`
+	Unfollows int    `json:"unfollows"`
+	Removed   int    `json:"removed"`
+	Net       int    `json:"net"`
+}
+
+// ChurnHour genuine unfollows happened within an hour of the day
+type ChurnHour struct {
+	Hour      int `json:"hour"`
+	Unfollows int `json:"unfollows"`
+}
+
+// invalidateStats drops cached stats, called whenever a sync has updated the buckets
+func (t *Tracker) invalidateStats() {
+	t.statsMu.Lock()
+	t.statsCache = make(map[string]Stats)
+	t.statsMu.Unlock()
+}
+
+// Stats returns cached stats or computes them for time bucket (day, week or month) and time zone
+func (t *Tracker) Stats(bucket string, timezone string) (Stats, error) {
+	if bucket != "day" && bucket != "week" && bucket != "month" {
+		return Stats{}, errors.New("Stats: bucket must be day, week or month")
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	key := bucket + "|" + timezone
+	t.statsMu.Lock()
+	defer t.statsMu.Unlock()
+	if stats, ok := t.statsCache[key]; ok {
+		return stats, nil
+	}
+
+	var stats Stats
+	err = t.store.View(func(tx storage.Tx) error {
+		stats = computeStats(tx, bucket, loc)
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+	stats.Timezone = timezone
+	t.statsCache[key] = stats
+	return stats, nil
+}
+
+// computeStats scans relationship buckets and event history
+func computeStats(tx storage.Tx, bucket string, loc *time.Location) Stats {
+	stats := Stats{Bucket: bucket, ComputedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	stats.Followers = tx.CountRelations(storage.ListFollowers)
+	tx.ForEachRelation(storage.ListUnfollowers, func(uid string, _ string) error {
+		if tx.Relation(storage.ListFollowers, uid) != "" {
+			stats.Refollows++
+		}
+		return nil
+	})
+	if n := tx.CountRelations(storage.ListUnfollowers); n > 0 {
+		stats.RefollowRatio = float64(stats.Refollows) / float64(n)
+	}
+
+	// Only unfollow events know when the user had followed
+	var durations []time.Duration
+	tx.ForEachEvent(func(e Event) error {
+		if e.Type != storage.EventUnfollow {
+			return nil
+		}
+		followedAt, err1 := time.Parse(time.RFC3339, e.FollowedAt)
+		unfollowedAt, err2 := time.Parse(time.RFC3339, e.At)
+		if err1 == nil && err2 == nil && unfollowedAt.After(followedAt) {
+			durations = append(durations, unfollowedAt.Sub(followedAt))
+		}
+		return nil
+	})
+	if len(durations) > 0 {
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		stats.AvgFollowDuration = (total / time.Duration(len(durations))).Seconds()
+	}
+
+	activities := getFollowActivity(tx)
+	reasons := UnfollowReasons(tx)
+	for _, a := range activities {
+		if a.eventType != storage.EventUnfollow {
+			stats.Follows++
+			continue
+		}
+		stats.Unfollows++
+		switch reasons[a.uid] {
+		case ReasonBanned:
+			stats.UnfollowsBanned++
+		case ReasonAccountUnavailable, ReasonAccountClosed:
+			stats.UnfollowsClosed++
+		default:
+			stats.GenuineUnfollows++
+		}
+	}
+	if stats.Follows > 0 {
+		stats.UnfollowRate = float64(stats.Unfollows) / float64(stats.Follows)
+		stats.GenuineUnfollowRate = float64(stats.GenuineUnfollows) / float64(stats.Follows)
+	}
+
+	// Net change per time bucket
+	growth := make(map[string]*GrowthStat)
+	get := func(t time.Time) *GrowthStat {
+		start := bucketStart(t.In(loc), bucket).Format(time.RFC3339)
+		if growth[start] == nil {
+			growth[start] = &GrowthStat{Start: start}
+		}
+		return growth[start]
+	}
+	hours := make([]int, 24)
+	for _, a := range activities {
+		g := get(a.at)
+		if a.eventType == storage.EventUnfollow {
+			g.Unfollows++
+			g.Net--
+			if reasons[a.uid] != "" {
+				g.Removed++
+			} else {
+				hours[a.at.In(loc).Hour()]++
+			}
+		} else {
+			g.Follows++
+			g.Net++
+		}
+	}
+
+	stats.Growth = []GrowthStat{}
+	for _, g := range growth {
+		stats.Growth = append(stats.Growth, *g)
+	}
+	sort.Slice(stats.Growth, func(i, j int) bool {
+		return stats.Growth[i].Start < stats.Growth[j].Start
+	})
+
+	// Hours of the day ordered by unfollows
+	stats.ChurnHours = []ChurnHour{}
+	for hour, n := range hours {
+		if n > 0 {
+			stats.ChurnHours = append(stats.ChurnHours, ChurnHour{hour, n})
+		}
+	}
+	sort.SliceStable(stats.ChurnHours, func(i, j int) bool {
+		return stats.ChurnHours[i].Unfollows > stats.ChurnHours[j].Unfollows
+	})
+
+	stats.Streams = getStreamStats(tx, activities)
+	return stats
+}
+
+// bucketStart truncates t to the start of its day, week (Monday) or month
+func bucketStart(t time.Time, bucket string) time.Time {
+	y, m, d := t.Date()
+	switch bucket {
+	case "week":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-weekday, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,111 @@
+package tracker
+
+import (
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// TwitchState what the latest Twitch API response told about rate limit and token
+type TwitchState struct {
+	LastStatus     int    `json:"lastStatus"`
+	LastResponseAt string `json:"lastResponseAt"`
+	TokenValid     bool   `json:"tokenValid"`
+	Limit          int    `json:"rateLimit"`
+	Remaining      int    `json:"rateLimitRemaining"`
+	ResetAt        string `json:"rateLimitResetAt"`
+}
+
+// trackTwitchResponse remembers the state of every Twitch API response, a 401 means ClientID or OAuth token were rejected
+func (t *Tracker) trackTwitchResponse(r twitch.Result) {
+	t.twitchMu.Lock()
+	defer t.twitchMu.Unlock()
+	t.twitchState.LastStatus = r.StatusCode
+	t.twitchState.LastResponseAt = time.Now().UTC().Format(time.RFC3339)
+	// Without a scope the token is still valid for everything else
+	if r.StatusCode == 401 && r.Scope == "" {
+		t.twitchState.TokenValid = false
+	} else if r.StatusCode == 200 {
+		t.twitchState.TokenValid = true
+	}
+	t.twitchState.Limit = r.Limit
+	t.twitchState.Remaining = r.LimitRemaining
+	t.twitchState.ResetAt = time.Unix(r.LimitReset, 0).UTC().Format(time.RFC3339)
+}
+
+func (t *Tracker) getTwitchState() TwitchState {
+	t.twitchMu.Lock()
+	defer t.twitchMu.Unlock()
+	return t.twitchState
+}
+
+// Status of this TUT process
+type Status struct {
+	Version           string       `json:"version"`
+	StartedAt         string       `json:"startedAt"`
+	ChannelID         string       `json:"channelID"`
+	Channel           string       `json:"channel"`
+	BaselineAt        string       `json:"baselineAt"`
+	Followers         int          `json:"followers"`
+	Following         int          `json:"following"`
+	EnrichmentBacklog int          `json:"enrichmentBacklog"`
+	Twitch            TwitchState  `json:"twitch"`
+	Syncs             []SyncStatus `json:"syncs"`
+	Jobs              []JobStatus  `json:"jobs"`
+	Roles             []RoleSync   `json:"roles"`
+	NextRun           string       `json:"nextRun"`
+}
+
+// Status collects the status of the store, the Twitch API and the scheduler
+func (t *Tracker) Status() (Status, error) {
+	status := Status{
+		Version:   Version,
+		StartedAt: t.startedAt.Format(time.RFC3339),
+		Twitch:    t.getTwitchState(),
+		Syncs:     t.Syncs(),
+		Jobs:      t.Jobs(),
+	}
+	for _, j := range status.Jobs {
+		if j.NextRun != "" && (status.NextRun == "" || j.NextRun < status.NextRun) {
+			status.NextRun = j.NextRun
+		}
+	}
+
+	err := t.store.View(func(tx storage.Tx) error {
+		status.ChannelID, _ = tx.Config("userID")
+		status.Channel, _ = tx.Config("username")
+		status.BaselineAt, _ = tx.Config(baselineKey)
+		status.Followers = tx.CountRelations(storage.ListFollowers)
+		status.Following = tx.CountRelations(storage.ListFollowing)
+		status.Roles = GetRoleSyncs(tx)
+		for _, list := range []string{storage.ListFollowers, storage.ListFollowing} {
+			tx.ForEachRelation(list, func(uid string, _ string) error {
+				if needsProfile(tx, uid) {
+					status.EnrichmentBacklog++
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return status, err
+}
+
+// NotReady lists why TUT is not ready yet: no follower sync completed since start up, or the token was rejected
+func (t *Tracker) NotReady() []string {
+	var reasons []string
+	synced := false
+	for _, j := range t.Jobs() {
+		if j.Name == "followers" && j.LastSuccess != "" {
+			synced = true
+		}
+	}
+	if !synced {
+		reasons = append(reasons, "first follower sync has not completed")
+	}
+	if !t.getTwitchState().TokenValid {
+		reasons = append(reasons, "Twitch rejected ClientID or OAuth token")
+	}
+	return reasons
+}
@@ -0,0 +1,291 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// StreamSession one broadcast of the tracked channel
+type StreamSession struct {
+	ID        string `json:"id"`
+	StartedAt string `json:"startedAt"`
+	EndedAt   string `json:"endedAt"`
+	Title     string `json:"title"`
+	Category  string `json:"category"`
+}
+
+// StreamEvent a follow or unfollow with the stream session it happened during or nearest to
+type StreamEvent struct {
+	Type        string `json:"type"`
+	UserID      string `json:"userID"`
+	Login       string `json:"login"`
+	Displayname string `json:"displayname"`
+	At          string `json:"at"`
+	StreamID    string `json:"streamID"`
+	Relation    string `json:"relation"`
+}
+
+// StreamStat follows and unfollows during and around a stream session
+type StreamStat struct {
+	StreamSession
+	FollowsDuring   int `json:"followsDuring"`
+	UnfollowsDuring int `json:"unfollowsDuring"`
+	FollowsAround   int `json:"followsAround"`
+	UnfollowsAround int `json:"unfollowsAround"`
+}
+
+// followActivity a follow or unfollow found in event history or relationship buckets
+type followActivity struct {
+	eventType string
+	uid       string
+	at        time.Time
+}
+
+// getFollowActivity collects follows and unfollows of the tracked channel, once each
+func getFollowActivity(tx storage.Tx) []followActivity {
+	seen := make(map[string]bool)
+	var activities []followActivity
+	add := func(eventType string, uid string, at string) {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil || seen[uid+"|"+at] {
+			return
+		}
+		seen[uid+"|"+at] = true
+		activities = append(activities, followActivity{eventType, uid, t})
+	}
+
+	tx.ForEachEvent(func(e Event) error {
+		switch e.Type {
+		case storage.EventFollow, storage.EventRefollow:
+			add(e.Type, e.UserID, e.FollowedAt)
+		case storage.EventUnfollow:
+			add(storage.EventFollow, e.UserID, e.FollowedAt)
+			add(storage.EventUnfollow, e.UserID, e.At)
+		}
+		return nil
+	})
+
+	// Data recorded before event history existed only lives in the relationship lists
+	tx.ForEachRelation(storage.ListFollowers, func(uid string, at string) error {
+		add(storage.EventFollow, uid, at)
+		return nil
+	})
+	tx.ForEachRelation(storage.ListUnfollowers, func(uid string, at string) error {
+		add(storage.EventUnfollow, uid, at)
+		return nil
+	})
+	return activities
+}
+
+// getStreamSessions reads stream sessions ordered by start time
+func getStreamSessions(tx storage.Tx) []StreamSession {
+	sessions := []StreamSession{}
+	tx.ForEach("streams", func(_ string, v []byte) error {
+		var session StreamSession
+		if json.Unmarshal(v, &session) == nil {
+			sessions = append(sessions, session)
+		}
+		return nil
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt < sessions[j].StartedAt
+	})
+	return sessions
+}
+
+// nearestSession finds the session t happened during, or else the one nearest to it within around, and
+// whether t was "during", "before" or "after" that session. Relation is empty when no session is close enough.
+func nearestSession(sessions []StreamSession, t time.Time, around time.Duration) (StreamSession, string) {
+	var nearest StreamSession
+	relation := ""
+	var distance time.Duration
+	for _, session := range sessions {
+		start, err := time.Parse(time.RFC3339, session.StartedAt)
+		if err != nil {
+			continue
+		}
+		// A session without end is still live
+		end := time.Now()
+		if session.EndedAt != "" {
+			end, _ = time.Parse(time.RFC3339, session.EndedAt)
+		}
+
+		var d time.Duration
+		var r string
+		switch {
+		case t.Before(start):
+			d, r = start.Sub(t), "before"
+		case t.After(end):
+			d, r = t.Sub(end), "after"
+		default:
+			return session, "during"
+		}
+		if d <= around && (relation == "" || d < distance) {
+			nearest, relation, distance = session, r, d
+		}
+	}
+	return nearest, relation
+}
+
+// streamAround reads how far from a session activity still counts around it
+func streamAround(tx storage.Tx) time.Duration {
+	return time.Duration(storage.IntSetting(tx, "streamAroundHours")) * time.Hour
+}
+
+// GetStreamEvents finds follows and unfollows that happened during or nearest to a stream session
+func GetStreamEvents(tx storage.Tx, streamID string) []StreamEvent {
+	events := []StreamEvent{}
+	sessions := getStreamSessions(tx)
+	around := streamAround(tx)
+	for _, a := range getFollowActivity(tx) {
+		session, relation := nearestSession(sessions, a.at, around)
+		if relation == "" || session.ID != streamID {
+			continue
+		}
+		e := StreamEvent{
+			Type:     a.eventType,
+			UserID:   a.uid,
+			At:       a.at.UTC().Format(time.RFC3339),
+			StreamID: session.ID,
+			Relation: relation,
+		}
+		if profile, ok := GetUserProfile(tx, a.uid); ok {
+			e.Login = profile["login"]
+			e.Displayname = profile["display_name"]
+		}
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].At < events[j].At
+	})
+	return events
+}
+
+// GetStreamStats counts follows and unfollows per stream session, newest session first
+func GetStreamStats(tx storage.Tx) []StreamStat {
+	return getStreamStats(tx, getFollowActivity(tx))
+}
+
+// getStreamStats counts activities per stream session, newest session first
+func getStreamStats(tx storage.Tx, activities []followActivity) []StreamStat {
+	sessions := getStreamSessions(tx)
+	stats := make(map[string]*StreamStat)
+	for _, session := range sessions {
+		stats[session.ID] = &StreamStat{StreamSession: session}
+	}
+	around := streamAround(tx)
+	for _, a := range activities {
+		session, relation := nearestSession(sessions, a.at, around)
+		stat, ok := stats[session.ID]
+		if relation == "" || !ok {
+			continue
+		}
+		switch {
+		case a.eventType == storage.EventUnfollow && relation == "during":
+			stat.UnfollowsDuring++
+		case a.eventType == storage.EventUnfollow:
+			stat.UnfollowsAround++
+		case relation == "during":
+			stat.FollowsDuring++
+		default:
+			stat.FollowsAround++
+		}
+	}
+
+	output := []StreamStat{}
+	for i := len(sessions) - 1; i >= 0; i-- {
+		output = append(output, *stats[sessions[i].ID])
+	}
+	return output
+}
+
+// updateStreams polls the live stream and past broadcasts of the tracked channel into streams bucket
+func (t *Tracker) updateStreams() {
+getStream:
+	result, liveStreams, _ := t.twitch.GetStreams(t.config.UserID)
+	if result.RateLimited() {
+		result.WaitForReset()
+		goto getStream
+	}
+	if result.StatusCode != 200 {
+		return
+	}
+
+getVideos:
+	result, videoStreams, _ := t.twitch.GetVideos(t.config.UserID)
+	if result.RateLimited() {
+		result.WaitForReset()
+		goto getVideos
+	}
+	live := toSessions(liveStreams)
+	videos := toSessions(videoStreams)
+
+	t.update(func(tx storage.Tx) error {
+		put := func(session StreamSession) error {
+			data, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+			return tx.Put("streams", session.ID, data)
+		}
+
+		liveIDs := make(map[string]bool)
+		for _, session := range live {
+			liveIDs[session.ID] = true
+			if tx.Get("streams", session.ID) == nil {
+				fmt.Printf("[INFO][STREAM] %s started: %s (%s)\n", session.Title, session.StartedAt, session.Category)
+			}
+			err := put(session)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Past broadcasts know the real end time
+		archived := make(map[string]StreamSession)
+		for _, session := range videos {
+			archived[session.ID] = session
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		for _, session := range getStreamSessions(tx) {
+			if session.EndedAt != "" || liveIDs[session.ID] {
+				continue
+			}
+			session.EndedAt = now
+			if video, ok := archived[session.ID]; ok && video.EndedAt != "" {
+				session.EndedAt = video.EndedAt
+			}
+			fmt.Printf("[INFO][STREAM] %s ended: %s\n", session.Title, session.EndedAt)
+			err := put(session)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Sessions from before TUT was running
+		for _, session := range videos {
+			if tx.Get("streams", session.ID) != nil || liveIDs[session.ID] {
+				continue
+			}
+			err := put(session)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// toSessions converts streams from Twitch to stream sessions
+func toSessions(streams []twitch.Stream) []StreamSession {
+	var sessions []StreamSession
+	for _, s := range streams {
+		sessions = append(sessions, StreamSession(s))
+	}
+	return sessions
+}
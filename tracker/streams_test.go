@@ -0,0 +1,32 @@
+package tracker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNearestSession(t *testing.T) {
+	sessions := []StreamSession{
+		{ID: "1", StartedAt: "2024-05-01T18:00:00Z", EndedAt: "2024-05-01T22:00:00Z"},
+		{ID: "2", StartedAt: "2024-05-03T18:00:00Z", EndedAt: "2024-05-03T22:00:00Z"},
+	}
+	for _, test := range []struct {
+		at       string
+		id       string
+		relation string
+	}{
+		{"2024-05-01T19:00:00Z", "1", "during"},
+		{"2024-05-01T16:00:00Z", "1", "before"},
+		{"2024-05-02T00:30:00Z", "1", "after"},
+		{"2024-05-03T16:30:00Z", "2", "before"},
+		// Too far from every session, like a follow from years before the first stream
+		{"2024-05-02T12:00:00Z", "", ""},
+		{"2021-01-01T00:00:00Z", "", ""},
+	} {
+		at, _ := time.Parse(time.RFC3339, test.at)
+		session, relation := nearestSession(sessions, at, 3*time.Hour)
+		if session.ID != test.id || relation != test.relation {
+			t.Errorf("%s: got session %q %q, want %q %q", test.at, session.ID, relation, test.id, test.relation)
+		}
+	}
+}
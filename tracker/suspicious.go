@@ -0,0 +1,240 @@
+package tracker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/devinjdawson/tut/storage"
+)
+
+// Score weights of every suspicious signal, a user scores at most 100
+const (
+	scoreBurst          = 30
+	scoreNewAccount     = 30
+	scoreGeneratedLogin = 20
+	scoreFollowUnfollow = 40
+)
+
+// SuspiciousUser a follower or former follower that looks like a follow-bot or follow-for-follow farmer
+type SuspiciousUser struct {
+	ID              string   `json:"id"`
+	Login           string   `json:"login"`
+	Displayname     string   `json:"displayname"`
+	ProfileImageURL string   `json:"profileImageURL"`
+	CreatedAt       string   `json:"createdAt"`
+	FollowedAt      string   `json:"followedAt"`
+	UnfollowedAt    string   `json:"unfollowedAt"`
+	Score           int      `json:"score"`
+	Reasons         []string `json:"reasons"`
+	Tags            []string `json:"tags,omitempty"`
+}
+
+type followSpan struct {
+	uid          string
+	followedAt   time.Time
+	unfollowedAt time.Time
+}
+
+// GetSuspiciousUsers scores every follower and former follower, highest score first
+func GetSuspiciousUsers(tx storage.Tx, minScore int) []SuspiciousUser {
+	burstWindow := time.Duration(storage.IntSetting(tx, "burstWindow")) * time.Minute
+	burstSize := storage.IntSetting(tx, "burstSize")
+	newAccount := time.Duration(storage.IntSetting(tx, "newAccountDays")) * 24 * time.Hour
+	followUnfollow := time.Duration(storage.IntSetting(tx, "followUnfollowHours")) * time.Hour
+
+	// Collect follow spans of current followers, and of former followers from unfollow events
+	spans := make(map[string]*followSpan)
+	tx.ForEachRelation(storage.ListFollowers, func(uid string, at string) error {
+		if t, err := time.Parse(time.RFC3339, at); err == nil {
+			spans[uid] = &followSpan{uid: uid, followedAt: t}
+		}
+		return nil
+	})
+	tx.ForEachEvent(func(e Event) error {
+		if e.Type != storage.EventUnfollow {
+			return nil
+		}
+		followedAt, err := time.Parse(time.RFC3339, e.FollowedAt)
+		if err != nil {
+			return nil
+		}
+		unfollowedAt, _ := time.Parse(time.RFC3339, e.At)
+		if _, stillFollowing := spans[e.UserID]; !stillFollowing {
+			spans[e.UserID] = &followSpan{uid: e.UserID, followedAt: followedAt, unfollowedAt: unfollowedAt}
+		}
+		return nil
+	})
+
+	reasons := make(map[string][]string)
+	scores := make(map[string]int)
+	flag := func(uid string, score int, reason string) {
+		scores[uid] += score
+		reasons[uid] = append(reasons[uid], reason)
+	}
+
+	// Bursts, at least burstSize follows within burstWindow, a burstWindow or burstSize of 0 or less disables them
+	ordered := make([]*followSpan, 0, len(spans))
+	for _, s := range spans {
+		ordered = append(ordered, s)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].followedAt.Before(ordered[j].followedAt)
+	})
+	inBurst := make(map[string]bool)
+	for i, j := 0, 0; j < len(ordered); j++ {
+		for i < j && ordered[j].followedAt.Sub(ordered[i].followedAt) > burstWindow {
+			i++
+		}
+		if burstSize > 0 && burstWindow > 0 && j-i+1 >= burstSize {
+			for _, s := range ordered[i : j+1] {
+				inBurst[s.uid] = true
+			}
+		}
+	}
+	for uid := range inBurst {
+		flag(uid, scoreBurst, fmt.Sprintf("followed during a burst of %d+ follows within %s", burstSize, burstWindow))
+	}
+
+	profiles := make(map[string]map[string]string)
+	for uid, s := range spans {
+		// Follow for follow, unfollowed within followUnfollow
+		if !s.unfollowedAt.IsZero() && s.unfollowedAt.Sub(s.followedAt) <= followUnfollow {
+			flag(uid, scoreFollowUnfollow, fmt.Sprintf("unfollowed %s after following", s.unfollowedAt.Sub(s.followedAt).Round(time.Minute)))
+		}
+
+		u, _ := tx.User(uid)
+		profile, ok := storage.ParseUserProfile(u.User)
+		if !ok {
+			continue
+		}
+		profile["created_at"] = userCreatedAt(u.User)
+		profiles[uid] = profile
+
+		if createdAt, err := time.Parse(time.RFC3339, profile["created_at"]); err == nil && s.followedAt.Sub(createdAt) <= newAccount {
+			flag(uid, scoreNewAccount, fmt.Sprintf("account created %s before following", s.followedAt.Sub(createdAt).Round(time.Minute)))
+		}
+		if generatedLogin(profile["login"]) {
+			flag(uid, scoreGeneratedLogin, "generated looking login")
+		}
+	}
+
+	var users []SuspiciousUser
+	for uid, score := range scores {
+		if score > 100 {
+			score = 100
+		}
+		if score < minScore {
+			continue
+		}
+		s := spans[uid]
+		profile := profiles[uid]
+		user := SuspiciousUser{
+			ID:              uid,
+			Login:           profile["login"],
+			Displayname:     profile["display_name"],
+			ProfileImageURL: profile["profile_image_url"],
+			CreatedAt:       profile["created_at"],
+			FollowedAt:      s.followedAt.UTC().Format(time.RFC3339),
+			Score:           score,
+			Reasons:         reasons[uid],
+		}
+		if !s.unfollowedAt.IsZero() {
+			user.UnfollowedAt = s.unfollowedAt.UTC().Format(time.RFC3339)
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Score != users[j].Score {
+			return users[i].Score > users[j].Score
+		}
+		return users[i].FollowedAt > users[j].FollowedAt
+	})
+	return users
+}
+
+// userCreatedAt reads account creation time from Helix user JSON
+func userCreatedAt(data []byte) string {
+	parsed, err := gabs.ParseJSON(data)
+	if err != nil {
+		return ""
+	}
+	createdAt, _ := parsed.Path("created_at").Data().(string)
+	return createdAt
+}
+
+// generatedLogin guesses whether a login was generated, e.g. "xyz_bot48213" or "qwrtzpl". Words like
+// "matchstick" have up to five consonants in a row and a year like "name2024" is not random, neither counts.
+func generatedLogin(login string) bool {
+	login = strings.ToLower(login)
+	if len(login) == 0 {
+		return false
+	}
+
+	digits, trailingDigits, consonantRun, longestConsonantRun := 0, 0, 0, 0
+	for _, c := range login {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+			trailingDigits++
+			consonantRun = 0
+		case strings.ContainsRune("aeiouy", c):
+			trailingDigits = 0
+			consonantRun = 0
+		case c >= 'a' && c <= 'z':
+			trailingDigits = 0
+			consonantRun++
+			if consonantRun > longestConsonantRun {
+				longestConsonantRun = consonantRun
+			}
+		default:
+			trailingDigits = 0
+			consonantRun = 0
+		}
+	}
+
+	if trailingDigits == 4 && (strings.HasSuffix(login[:len(login)-2], "19") || strings.HasSuffix(login[:len(login)-2], "20")) {
+		trailingDigits = 0
+		digits -= 4
+	}
+	return trailingDigits >= 4 ||
+		float64(digits)/float64(len(login)) > 0.4 ||
+		longestConsonantRun >= 6
+}
+
+// alertSuspicious prints an alert and records an event for every newly suspicious user
+func (t *Tracker) alertSuspicious() {
+	t.update(func(tx storage.Tx) error {
+		if !storage.BoolSetting(tx, "suspiciousAlerts") {
+			return nil
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		for _, user := range GetSuspiciousUsers(tx, storage.IntSetting(tx, "suspiciousScore")) {
+			if tx.Get("suspiciousAlerted", user.ID) != nil {
+				continue
+			}
+			fmt.Printf("[ALERT][SUSPICIOUS] %s (%s) [%s] Score: %d, %s\n", user.Displayname, user.Login, user.ID, user.Score, strings.Join(user.Reasons, ", "))
+			err := tx.Put("suspiciousAlerted", user.ID, []byte(now))
+			if err != nil {
+				return err
+			}
+			err = recordEvent(tx, Event{
+				Type:        storage.EventSuspicious,
+				UserID:      user.ID,
+				Login:       user.Login,
+				Displayname: user.Displayname,
+				At:          now,
+				FollowedAt:  user.FollowedAt,
+				Details:     map[string]string{"score": fmt.Sprint(user.Score), "reasons": strings.Join(user.Reasons, ", ")},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
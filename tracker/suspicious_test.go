@@ -0,0 +1,30 @@
+package tracker
+
+import "testing"
+
+func TestGeneratedLogin(t *testing.T) {
+	for _, test := range []struct {
+		login string
+		want  bool
+	}{
+		{"qwrtzpl", true},
+		{"xyz_bot48213", true},
+		{"user38271", true},
+		{"k9x7z2m4", true},
+		{"bcdfghjk_tv", true},
+		{"nightstalker", false},
+		{"matchstick", false},
+		{"strengths", false},
+		{"name2024", false},
+		{"gamer1999", false},
+		{"Speedrunner_2007", false},
+		{"xXsniperXx", false},
+		{"pixel42", false},
+		{"ninja", false},
+		{"", false},
+	} {
+		if got := generatedLogin(test.login); got != test.want {
+			t.Errorf("generatedLogin(%q) = %v, want %v", test.login, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,516 @@
+package tracker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// SyncStatus progress of the sync pipeline of one relationship list, Pages and Users count the current or last run
+type SyncStatus struct {
+	List         string `json:"list"`
+	Running      bool   `json:"running"`
+	Pages        int    `json:"pages"`
+	Users        int    `json:"users"`
+	LastStart    string `json:"lastStart"`
+	LastEnd      string `json:"lastEnd"`
+	LastError    string `json:"lastError,omitempty"`
+	LastComplete string `json:"lastComplete"`
+}
+
+// Sync syncs the given relationship lists, followers and / or following, each in its own pipeline
+// and all at the same time. While a baseline is pending both are synced and recorded without reporting them.
+func (t *Tracker) Sync(lists ...string) error {
+	// Whoever finds the baseline pending records it, everyone else waits for it
+	t.baselineMu.Lock()
+	var baseline bool
+	t.store.View(func(tx storage.Tx) error {
+		baseline = needsBaseline(tx)
+		return nil
+	})
+	if baseline {
+		defer t.baselineMu.Unlock()
+		fmt.Printf("[SYS] Recording baseline, current followers and following are not reported...\n")
+		err := t.syncLists([]string{storage.ListFollowers, storage.ListFollowing}, true)
+		if err != nil {
+			return err
+		}
+		return t.recordBaseline()
+	}
+	t.baselineMu.Unlock()
+	return t.syncLists(lists, false)
+}
+
+// syncLists runs the pipelines of lists concurrently and returns the first error
+func (t *Tracker) syncLists(lists []string, baseline bool) error {
+	errs := make([]error, len(lists))
+	var wg sync.WaitGroup
+	for i, list := range lists {
+		wg.Add(1)
+		go func(i int, list string) {
+			defer wg.Done()
+			errs[i] = t.syncList(list, baseline)
+		}(i, list)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncList runs the pipeline of one list, never twice at the same time, and tracks its progress
+func (t *Tracker) syncList(list string, baseline bool) error {
+	var pipeline func(baseline bool) error
+	switch list {
+	case storage.ListFollowers:
+		pipeline = t.syncFollowers
+	case storage.ListFollowing:
+		pipeline = t.syncFollowing
+	default:
+		return fmt.Errorf("syncList: cannot sync %q, only followers and following", list)
+	}
+	t.listMu[list].Lock()
+	defer t.listMu[list].Unlock()
+
+	t.setSyncStatus(list, func(s *SyncStatus) {
+		s.Running = true
+		s.Pages = 0
+		s.Users = 0
+		s.LastStart = time.Now().UTC().Format(time.RFC3339)
+	})
+	err := pipeline(baseline)
+	t.setSyncStatus(list, func(s *SyncStatus) {
+		s.Running = false
+		s.LastEnd = time.Now().UTC().Format(time.RFC3339)
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		} else {
+			s.LastComplete = s.LastEnd
+		}
+	})
+	return err
+}
+
+func (t *Tracker) setSyncStatus(list string, update func(s *SyncStatus)) {
+	t.syncMu.Lock()
+	defer t.syncMu.Unlock()
+	s, ok := t.syncStatus[list]
+	if !ok {
+		s = &SyncStatus{List: list}
+		t.syncStatus[list] = s
+	}
+	update(s)
+}
+
+// Syncs lists the progress of the followers and following pipelines
+func (t *Tracker) Syncs() []SyncStatus {
+	t.syncMu.Lock()
+	defer t.syncMu.Unlock()
+	status := []SyncStatus{}
+	for _, list := range []string{storage.ListFollowers, storage.ListFollowing} {
+		if s, ok := t.syncStatus[list]; ok {
+			status = append(status, *s)
+		} else {
+			status = append(status, SyncStatus{List: list})
+		}
+	}
+	return status
+}
+
+// countPage adds a page read by the pipeline of list to its progress
+func (t *Tracker) countPage(list string, users int) {
+	t.setSyncStatus(list, func(s *SyncStatus) {
+		s.Pages++
+		s.Users += users
+	})
+}
+
+// syncFollowers finds new followers, refollowers and unfollowers. Unless every page could be read
+// nobody is taken for an unfollower.
+func (t *Tracker) syncFollowers(baseline bool) error {
+	// Get all followers and unfollowers from previous snippet
+	followMap := make(map[string]string)
+	unfollowMap := make(map[string]string)
+	t.store.View(func(tx storage.Tx) error {
+		tx.ForEachRelation(storage.ListFollowers, func(uid string, at string) error {
+			followMap[uid] = at
+			return nil
+		})
+		tx.ForEachRelation(storage.ListUnfollowers, func(uid string, at string) error {
+			unfollowMap[uid] = at
+			return nil
+		})
+		return nil
+	})
+
+	// Get next page if there is any
+	var Fpage string
+	for {
+		var FtoAdd []twitch.Follow
+		var toRecord []Event
+		Fresult, Fout, err := t.twitch.GetFollowers(t.config.UserID, Fpage)
+
+		if Fresult.RateLimited() {
+			Fresult.WaitForReset()
+			continue
+		}
+
+		if len(Fout) == 0 {
+			if Fresult.StatusCode == 0 {
+				return fmt.Errorf("syncFollowers: %v", err)
+			}
+			if Fresult.StatusCode != 200 {
+				return fmt.Errorf("syncFollowers: Twitch API answered %d", Fresult.StatusCode)
+			}
+			break
+		}
+
+		Fpage = Fresult.Response["next"]
+		t.countPage(storage.ListFollowers, len(Fout))
+
+		// Filter out followers
+		for _, follower := range Fout {
+			_, exist := followMap[follower.UserID]
+			if exist {
+				delete(followMap, follower.UserID)
+			} else if baseline {
+				FtoAdd = append(FtoAdd, follower)
+			} else {
+				_, refollow := unfollowMap[follower.UserID]
+
+				if refollow {
+					// Try to find user data in user bucket
+					var displayname, login string
+					t.store.View(func(tx storage.Tx) error {
+						if profile, ok := GetUserProfile(tx, follower.UserID); ok {
+							displayname = profile["display_name"]
+							login = profile["login"]
+						}
+						return nil
+					})
+
+					// If user data is not presetned in user bucket, we querry twitch API
+					if displayname == "" && login == "" {
+					getUserNameInRefollow:
+						result, _ := t.twitch.GetUserName(follower.UserID)
+						if result.RateLimited() {
+							result.WaitForReset()
+							goto getUserNameInRefollow
+						}
+						displayname = result.Response["displayname"]
+						login = result.Response["login"]
+					}
+
+					fmt.Printf("[INFO][RE-FOLLOW] %s (%s) [%s] Followed: %s\n", displayname, login, follower.UserID, follower.FollowedAt)
+					toRecord = append(toRecord, Event{Type: storage.EventRefollow, UserID: follower.UserID, Login: login, Displayname: displayname, FollowedAt: follower.FollowedAt})
+				} else {
+					fmt.Printf("[INFO][FOLLOW] UID: %s Followed: %s\n", follower.UserID, follower.FollowedAt)
+					toRecord = append(toRecord, Event{Type: storage.EventFollow, UserID: follower.UserID, FollowedAt: follower.FollowedAt})
+				}
+				FtoAdd = append(FtoAdd, follower)
+			}
+		}
+
+		// Commit changes
+		err = t.update(func(tx storage.Tx) error {
+			for _, v := range FtoAdd {
+				err := tx.PutRelation(storage.ListFollowers, v.UserID, v.FollowedAt)
+				if err != nil {
+					return err
+				}
+			}
+			return recordEvents(tx, toRecord)
+		})
+		if err != nil {
+			return fmt.Errorf("syncFollowers: %v", err)
+		}
+
+		// The last page may come without a cursor, asking again without one would start over at the first page
+		if Fpage == "" {
+			break
+		}
+	}
+
+	// Found unfollower
+	for k, v := range followMap {
+	getUserNameInUnfollow:
+		result, err := t.twitch.GetUser(k)
+		if result.RateLimited() {
+			result.WaitForReset()
+			goto getUserNameInUnfollow
+		}
+		if result.StatusCode == 0 {
+			// Without an answer it is unknown whether the account still exists
+			return fmt.Errorf("syncFollowers: %v", err)
+		}
+
+		unfollowEvent := Event{Type: storage.EventUnfollow, UserID: k, FollowedAt: v}
+		parsed, err := gabs.ParseJSON([]byte(result.Response["user"]))
+		found := err == nil
+		if !found {
+			// Twitch answers without user for deleted and suspended accounts, keep the profile TUT knows
+			unfollowEvent.Login, unfollowEvent.Displayname = t.lastKnownName(k)
+		} else {
+			userdata, err := parsed.ChildrenMap()
+			if err != nil {
+				return err
+			}
+			unfollowEvent.Login, _ = userdata["login"].Data().(string)
+			unfollowEvent.Displayname, _ = userdata["display_name"].Data().(string)
+		}
+		reason := ""
+		if found || result.StatusCode == 200 {
+			reason = t.unfollowReason(k, found)
+		}
+		if reason != "" {
+			unfollowEvent.Details = map[string]string{"reason": reason}
+		}
+		switch {
+		case reason == ReasonBanned:
+			fmt.Printf("[INFO][UNFOLLOW / Banned] %s (%s) [%s], Followed: %s\n", unfollowEvent.Displayname, unfollowEvent.Login, k, v)
+		case reason == ReasonAccountUnavailable:
+			fmt.Printf("[INFO][UNFOLLOW / Account unavailable] %s (%s) [%s], Followed: %s\n", unfollowEvent.Displayname, unfollowEvent.Login, k, v)
+		case !found:
+			fmt.Printf("[INFO][UNFOLLOW / Profile unknown] [%s], Followed: %s\n", k, v)
+		default:
+			fmt.Printf("[INFO][UNFOLLOW] %s (%s) [%s], Followed: %s\n", unfollowEvent.Displayname, unfollowEvent.Login, k, v)
+		}
+
+		err = t.update(func(tx storage.Tx) error {
+			// remove the unfollower from followers
+			err := tx.DeleteRelation(storage.ListFollowers, k)
+			if err != nil {
+				return err
+			}
+
+			// Add the unfollower to the unfollowers
+			unfollowEvent.At = time.Now().UTC().Format(time.RFC3339)
+			err = tx.PutRelation(storage.ListUnfollowers, k, unfollowEvent.At)
+			if err != nil {
+				return err
+			}
+			err = recordEvent(tx, unfollowEvent)
+			if err != nil {
+				return err
+			}
+			return storeFetchedUser(tx, k, found, result)
+		})
+		if err != nil {
+			return fmt.Errorf("syncFollowers: %v", err)
+		}
+	}
+	return nil
+}
+
+// syncFollowing finds newly followed, refollowed and unfollowed channels. Unless every page could be read
+// nobody is taken for unfollowed.
+func (t *Tracker) syncFollowing(baseline bool) error {
+	// Get all following and unfollowing from previous snippet
+	followedMap := make(map[string]string)
+	unfollowedMap := make(map[string]string)
+	t.store.View(func(tx storage.Tx) error {
+		tx.ForEachRelation(storage.ListFollowing, func(uid string, at string) error {
+			followedMap[uid] = at
+			return nil
+		})
+		tx.ForEachRelation(storage.ListUnfollowing, func(uid string, at string) error {
+			unfollowedMap[uid] = at
+			return nil
+		})
+		return nil
+	})
+
+	// Get next page if there is any
+	var Opage string
+	for {
+		var OtoAdd []twitch.Follow
+		var toRecord []Event
+		Oresult, Oout, err := t.twitch.GetFollowing(t.config.UserID, Opage)
+
+		if Oresult.RateLimited() {
+			Oresult.WaitForReset()
+			continue
+		}
+
+		if len(Oout) == 0 {
+			if Oresult.StatusCode == 0 {
+				return fmt.Errorf("syncFollowing: %v", err)
+			}
+			if Oresult.StatusCode != 200 {
+				return fmt.Errorf("syncFollowing: Twitch API answered %d", Oresult.StatusCode)
+			}
+			break
+		}
+
+		Opage = Oresult.Response["next"]
+		t.countPage(storage.ListFollowing, len(Oout))
+
+		// Filter out following
+		for _, followed := range Oout {
+			_, exist := followedMap[followed.UserID]
+			if exist {
+				delete(followedMap, followed.UserID)
+			} else if baseline {
+				OtoAdd = append(OtoAdd, followed)
+			} else {
+				_, refollowed := unfollowedMap[followed.UserID]
+
+				if refollowed {
+					// Try to find user data in user bucket
+					var displayname, login string
+					t.store.View(func(tx storage.Tx) error {
+						if profile, ok := GetUserProfile(tx, followed.UserID); ok {
+							displayname = profile["display_name"]
+							login = profile["login"]
+						}
+						return nil
+					})
+
+					// If user data is not presetned in user bucket, we querry twitch API
+					if displayname == "" && login == "" {
+					getUserNameInRefollowed:
+						result, _ := t.twitch.GetUserName(followed.UserID)
+						if result.RateLimited() {
+							result.WaitForReset()
+							goto getUserNameInRefollowed
+						}
+						displayname = result.Response["displayname"]
+						login = result.Response["login"]
+					}
+
+					fmt.Printf("[INFO][RE-FOLLOWED] %s (%s) [%s] Followed: %s\n", displayname, login, followed.UserID, followed.FollowedAt)
+					toRecord = append(toRecord, Event{Type: storage.EventRefollowed, UserID: followed.UserID, Login: login, Displayname: displayname, FollowedAt: followed.FollowedAt})
+				} else {
+					fmt.Printf("[INFO][FOLLOWS] UID: %s Follows: %s\n", followed.UserID, followed.FollowedAt)
+					toRecord = append(toRecord, Event{Type: storage.EventFollows, UserID: followed.UserID, FollowedAt: followed.FollowedAt})
+				}
+				OtoAdd = append(OtoAdd, followed)
+			}
+		}
+
+		// Commit changes
+		err = t.update(func(tx storage.Tx) error {
+			for _, v := range OtoAdd {
+				err := tx.PutRelation(storage.ListFollowing, v.UserID, v.FollowedAt)
+				if err != nil {
+					return err
+				}
+			}
+			return recordEvents(tx, toRecord)
+		})
+		if err != nil {
+			return fmt.Errorf("syncFollowing: %v", err)
+		}
+
+		// The last page may come without a cursor, asking again without one would start over at the first page
+		if Opage == "" {
+			break
+		}
+	}
+
+	// Found unfollowing
+	for k, v := range followedMap {
+	getUserNameInUnfollowed:
+		result, err := t.twitch.GetUser(k)
+		if result.RateLimited() {
+			result.WaitForReset()
+			goto getUserNameInUnfollowed
+		}
+		if result.StatusCode == 0 {
+			return fmt.Errorf("syncFollowing: %v", err)
+		}
+
+		unfollowEvent := Event{Type: storage.EventUnfollowed, UserID: k, FollowedAt: v}
+		parsed, err := gabs.ParseJSON([]byte(result.Response["user"]))
+		found := err == nil
+		if !found && result.StatusCode == 200 {
+			unfollowEvent.Login, unfollowEvent.Displayname = t.lastKnownName(k)
+			unfollowEvent.Details = map[string]string{"reason": ReasonAccountUnavailable}
+			fmt.Printf("[INFO][UNFOLLOWED / Account unavailable] %s (%s) [%s], Followed: %s\n", unfollowEvent.Displayname, unfollowEvent.Login, k, v)
+		} else if !found {
+			fmt.Printf("[INFO][UNFOLLOWED / Profile unknown] [%s], Followed: %s\n", k, v)
+		} else {
+			userdata, err := parsed.ChildrenMap()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("[INFO][UNFOLLOWED] %s (%s) [%s], Followed: %s\n", userdata["display_name"].Data().(string), userdata["login"].Data().(string), k, v)
+			unfollowEvent.Login, _ = userdata["login"].Data().(string)
+			unfollowEvent.Displayname, _ = userdata["display_name"].Data().(string)
+		}
+
+		err = t.update(func(tx storage.Tx) error {
+			// remove the unfollowed user from following
+			err := tx.DeleteRelation(storage.ListFollowing, k)
+			if err != nil {
+				return err
+			}
+
+			// Add the unfollowed user to the unfollowing
+			unfollowEvent.At = time.Now().UTC().Format(time.RFC3339)
+			err = tx.PutRelation(storage.ListUnfollowing, k, unfollowEvent.At)
+			if err != nil {
+				return err
+			}
+			err = recordEvent(tx, unfollowEvent)
+			if err != nil {
+				return err
+			}
+			return storeFetchedUser(tx, k, found, result)
+		})
+		if err != nil {
+			return fmt.Errorf("syncFollowing: %v", err)
+		}
+	}
+	return nil
+}
+
+// updateUsers fetches the profiles of followers and following TUT doesn't have yet. A rate limit ends the run
+// early, the next one goes on. Twitch is asked outside of any transaction, each profile is stored on its own so
+// the syncs aren't held up.
+func (t *Tracker) updateUsers() error {
+	var missing []string
+	seen := make(map[string]bool)
+	t.store.View(func(tx storage.Tx) error {
+		for _, list := range []string{storage.ListFollowers, storage.ListFollowing} {
+			tx.ForEachRelation(list, func(uid string, _ string) error {
+				if !seen[uid] && needsProfile(tx, uid) {
+					seen[uid] = true
+					missing = append(missing, uid)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	for _, uid := range missing {
+		result, err := t.twitch.GetUser(uid)
+		if result.RateLimited() {
+			result.WaitForReset()
+			return nil
+		}
+		if result.StatusCode == 0 {
+			return fmt.Errorf("updateUsers: %v", err)
+		}
+		if result.StatusCode != 200 {
+			return fmt.Errorf("updateUsers: Twitch API answered %d", result.StatusCode)
+		}
+		found := err == nil
+		err = t.update(func(tx storage.Tx) error {
+			return storeFetchedUser(tx, uid, found, result)
+		})
+		if err != nil {
+			return fmt.Errorf("updateUsers: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package tracker
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// lastPageHelix answers follows with one user and no cursor, the way Helix may end a listing, and counts
+// the requests. Repeated requests get an empty page so a sync that starts over still ends.
+type lastPageHelix struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (h *lastPageHelix) RoundTrip(r *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	direction := "to_id"
+	if r.URL.Query().Get("from_id") != "" {
+		direction = "from_id"
+	}
+	h.calls[direction]++
+	calls := h.calls[direction]
+	h.mu.Unlock()
+
+	body := `{"data":[]}`
+	if r.URL.Path == "/helix/users/follows" && calls == 1 {
+		body = `{"total":1,"data":[{"from_id":"1","to_id":"1","followed_at":"2024-05-01T10:00:00Z"}],"pagination":{}}`
+	}
+	header := http.Header{}
+	header.Set("Ratelimit-Limit", "800")
+	header.Set("Ratelimit-Remaining", "799")
+	header.Set("Ratelimit-Reset", fmt.Sprint(time.Now().Unix()+60))
+	return &http.Response{StatusCode: 200, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(body)), Request: r}, nil
+}
+
+func TestSyncStopsAtPageWithoutCursor(t *testing.T) {
+	helix := &lastPageHelix{calls: make(map[string]int)}
+	transport := http.DefaultTransport
+	http.DefaultTransport = helix
+	t.Cleanup(func() { http.DefaultTransport = transport })
+
+	store, err := storage.Open("bolt", filepath.Join(t.TempDir(), "TUT.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	err = store.Update(func(tx storage.Tx) error { return tx.SetConfig(baselineKey, "2024-01-01T00:00:00Z") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := New(store, Config{ClientID: "client", OAuth: "token", Username: "somechannel", UserID: "999", UpdateInterval: 60})
+	err = tr.Sync(storage.ListFollowers, storage.ListFollowing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if helix.calls["to_id"] != 1 || helix.calls["from_id"] != 1 {
+		t.Errorf("asked for follows %d times and following %d times, want once each", helix.calls["to_id"], helix.calls["from_id"])
+	}
+	var events []Event
+	store.View(func(tx storage.Tx) error {
+		return tx.ForEachEvent(func(e Event) error {
+			events = append(events, e)
+			return nil
+		})
+	})
+	if len(events) != 2 {
+		t.Errorf("recorded %+v, want one follow and one following", events)
+	}
+}
@@ -0,0 +1,158 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// Tags with a meaning to TUT, other tags only label users
+const (
+	// TagIgnore users are never reported, e.g. own alt accounts and bots like Nightbot
+	TagIgnore = "ignore"
+	// TagWatch users are always alerted on, by email and hooks whatever event types they are set to
+	TagWatch = "watch"
+	TagVIP   = "vip"
+)
+
+// tagsBucket keeps the sorted tags of every tagged user by user ID
+const tagsBucket = "tags"
+
+var validTag = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ValidTag tells whether tag is lowercase letters, digits, - and _, at most 32 of them
+func ValidTag(tag string) bool {
+	return validTag.MatchString(tag)
+}
+
+// HasTag tells whether tags contains tag
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTags reads the tags of a user, none if untagged
+func GetTags(tx storage.Tx, uid string) []string {
+	var tags []string
+	json.Unmarshal(tx.Get(tagsBucket, uid), &tags)
+	return tags
+}
+
+// AllTags reads the tags of every tagged user by user ID
+func AllTags(tx storage.Tx) map[string][]string {
+	all := make(map[string][]string)
+	tx.ForEach(tagsBucket, func(uid string, data []byte) error {
+		var tags []string
+		if json.Unmarshal(data, &tags) == nil {
+			all[uid] = tags
+		}
+		return nil
+	})
+	return all
+}
+
+// AddTag tags a user, tagging twice is no error
+func AddTag(tx storage.Tx, uid string, tag string) error {
+	if !ValidTag(tag) {
+		return fmt.Errorf("AddTag: invalid tag %q, use lowercase letters, digits, - and _", tag)
+	}
+	tags := GetTags(tx, uid)
+	if HasTag(tags, tag) {
+		return nil
+	}
+	tags = append(tags, tag)
+	sort.Strings(tags)
+	return putTags(tx, uid, tags)
+}
+
+// RemoveTag removes a tag of a user, false if the user didn't have it
+func RemoveTag(tx storage.Tx, uid string, tag string) (bool, error) {
+	var kept []string
+	removed := false
+	for _, t := range GetTags(tx, uid) {
+		if t == tag {
+			removed = true
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, putTags(tx, uid, kept)
+}
+
+func putTags(tx storage.Tx, uid string, tags []string) error {
+	if len(tags) == 0 {
+		return tx.Delete(tagsBucket, uid)
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return tx.Put(tagsBucket, uid, data)
+}
+
+// Watched tells whether e is of a user tagged watch and worth an alert, which profile fetches are not
+func Watched(e Event) bool {
+	return e.Type != storage.EventEnrichment && HasTag(strings.Split(e.Details["tags"], ","), TagWatch)
+}
+
+// tagEvents leaves out events of ignored users and adds the tags and roles of the others to a copy of their
+// details as "tags", "roles" and "subTier"
+func tagEvents(tx storage.Tx, events []Event) []Event {
+	all := AllTags(tx)
+	var tagged []Event
+	for _, e := range events {
+		tags := all[e.UserID]
+		if HasTag(tags, TagIgnore) {
+			continue
+		}
+		roles := GetRoles(tx, e.UserID)
+		if len(tags) > 0 || roles != nil {
+			details := make(map[string]string)
+			if len(tags) > 0 {
+				details["tags"] = strings.Join(tags, ",")
+			}
+			if roles != nil {
+				details["roles"] = roles.String()
+				if roles.SubTier != "" {
+					details["subTier"] = roles.SubTier
+				}
+			}
+			for k, v := range e.Details {
+				details[k] = v
+			}
+			e.Details = details
+		}
+		tagged = append(tagged, e)
+	}
+	return tagged
+}
+
+// ResolveUser finds the user ID of an ID or login, asking Twitch for logins TUT doesn't know,
+// e.g. to tag a bot before it follows
+func (t *Tracker) ResolveUser(idOrLogin string) (string, error) {
+	var uid string
+	t.store.View(func(tx storage.Tx) error {
+		uid = ResolveUserID(tx, idOrLogin)
+		return nil
+	})
+	if uid != idOrLogin || strings.Trim(idOrLogin, "0123456789") == "" {
+		return uid, nil
+	}
+	result, err := t.twitch.GetUserID(strings.ToLower(idOrLogin))
+	if err != nil {
+		return "", errors.New("ResolveUser: no user " + idOrLogin)
+	}
+	return result.Response["id"], nil
+}
@@ -0,0 +1,91 @@
+package tracker
+
+import (
+	"sort"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// Timeline everything TUT knows about one user
+type Timeline struct {
+	User    UserDetail      `json:"user"`
+	Entries []TimelineEntry `json:"entries"`
+}
+
+// TimelineEntry one follow, unfollow, refollow, profile change or enrichment of a user
+type TimelineEntry struct {
+	At      string            `json:"at"`
+	Type    string            `json:"type"`
+	Source  string            `json:"source"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// GetTimeline builds an ordered timeline of a user from event history and relationship buckets
+func GetTimeline(tx storage.Tx, uid string) (Timeline, bool) {
+	detail, known := GetUserDetail(tx, uid)
+	timeline := Timeline{User: detail, Entries: []TimelineEntry{}}
+
+	events := tx.UserEvents(uid)
+	if !known && len(events) == 0 {
+		return timeline, false
+	}
+
+	// Remember which relationship timestamps are already covered by event history
+	covered := make(map[string]bool)
+	for _, e := range events {
+		entry := TimelineEntry{At: e.At, Type: e.Type, Source: "events", Details: e.Details}
+		if e.FollowedAt != "" {
+			if entry.Details == nil {
+				entry.Details = make(map[string]string)
+			}
+			entry.Details["followedAt"] = e.FollowedAt
+			covered[e.Type+e.FollowedAt] = true
+		}
+		covered[e.Type+e.At] = true
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	// Data recorded before event history existed only lives in the relationship buckets
+	rel := detail.Relationship
+	for _, b := range []struct {
+		bucket    string
+		eventType string
+		at        string
+	}{
+		{"followers", storage.EventFollow, rel.FollowedAt},
+		{"unfollowers", storage.EventUnfollow, rel.UnfollowedAt},
+		{"following", storage.EventFollows, rel.FollowingAt},
+		{"unfollowing", storage.EventUnfollowed, rel.UnfollowingAt},
+	} {
+		if b.at == "" {
+			continue
+		}
+		eventType := b.eventType
+		if eventType == storage.EventFollow && rel.RefollowedAt != "" {
+			eventType = storage.EventRefollow
+		}
+		if eventType == storage.EventFollows && rel.RefollowingAt != "" {
+			eventType = storage.EventRefollowed
+		}
+		if covered[eventType+b.at] {
+			continue
+		}
+		timeline.Entries = append(timeline.Entries, TimelineEntry{At: b.at, Type: eventType, Source: b.bucket})
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].At < timeline.Entries[j].At
+	})
+	return timeline, true
+}
+
+// ResolveUserID accepts either a numeric user ID or a login
+func ResolveUserID(tx storage.Tx, idOrLogin string) string {
+	if _, known := GetUserDetail(tx, idOrLogin); known {
+		return idOrLogin
+	}
+	if id := tx.LookupLogin(idOrLogin); id != "" {
+		return id
+	}
+	return idOrLogin
+}
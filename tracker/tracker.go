@@ -0,0 +1,155 @@
+// Package tracker syncs followers and following of a Twitch channel into a store, reports follows,
+// unfollows and refollows to event handlers, and computes stats over the history.
+//
+//	t := tracker.New(store, tracker.Config{ClientID: id, OAuth: token, Username: "me", UserID: "123", UpdateInterval: 60})
+//	t.OnEvent(func(e tracker.Event) {
+//		if e.Type == storage.EventUnfollow {
+//			bot.Say(e.Displayname + " unfollowed")
+//		}
+//	})
+//	t.Run()
+package tracker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+	"github.com/devinjdawson/tut/twitch"
+)
+
+// Config of the tracked channel
+type Config struct {
+	ClientID       string
+	OAuth          string
+	Username       string
+	UserID         string
+	UpdateInterval int // minutes
+}
+
+// Event something the tracker detected and recorded, Type is one of the storage.Event* types
+type Event = storage.Event
+
+// Tracker tracks one channel
+type Tracker struct {
+	store     storage.Store
+	twitch    *twitch.Client
+	config    Config
+	startedAt time.Time
+
+	handlersMu sync.Mutex
+	handlers   []func(e Event)
+	emitMu     sync.Mutex
+
+	baselineMu sync.Mutex
+	listMu     map[string]*sync.Mutex
+	syncMu     sync.Mutex
+	syncStatus map[string]*SyncStatus
+
+	jobs         []job
+	scheduleMu   sync.Mutex
+	jobStatus    map[string]*JobStatus
+	syncRequests chan string
+
+	twitchMu    sync.Mutex
+	twitchState TwitchState
+
+	statsMu    sync.Mutex
+	statsCache map[string]Stats
+}
+
+// New creates a tracker of the channel in c, keeping its data in store
+func New(store storage.Store, c Config) *Tracker {
+	t := &Tracker{
+		store:       store,
+		config:      c,
+		startedAt:   time.Now().UTC(),
+		listMu:      map[string]*sync.Mutex{storage.ListFollowers: {}, storage.ListFollowing: {}},
+		syncStatus:  make(map[string]*SyncStatus),
+		jobStatus:   make(map[string]*JobStatus),
+		twitchState: TwitchState{TokenValid: true},
+		statsCache:  make(map[string]Stats),
+	}
+	t.twitch = &twitch.Client{ClientID: c.ClientID, OAuth: c.OAuth, OnResponse: t.trackTwitchResponse}
+	t.jobs = t.defaultJobs()
+	t.syncRequests = make(chan string, len(t.jobs))
+	return t
+}
+
+// Store the tracker keeps its data in
+func (t *Tracker) Store() storage.Store {
+	return t.store
+}
+
+// Config of the tracked channel
+func (t *Tracker) Config() Config {
+	return t.config
+}
+
+// OnEvent adds a handler called with every event once it is recorded. Handlers run one at a time on
+// a goroutine that syncs, and should hand off anything slow.
+func (t *Tracker) OnEvent(handler func(e Event)) {
+	t.handlersMu.Lock()
+	defer t.handlersMu.Unlock()
+	t.handlers = append(t.handlers, handler)
+}
+
+// emit calls the event handlers, events of concurrent syncs wait for each other. Events of ignored users are
+// left out, the others carry the tags of their user.
+func (t *Tracker) emit(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	t.store.View(func(tx storage.Tx) error {
+		events = tagEvents(tx, events)
+		return nil
+	})
+	t.handlersMu.Lock()
+	handlers := t.handlers
+	t.handlersMu.Unlock()
+	t.emitMu.Lock()
+	defer t.emitMu.Unlock()
+	for _, e := range events {
+		if Watched(e) {
+			name := e.UserID
+			if e.Login != "" {
+				name = fmt.Sprintf("%s (%s) [%s]", e.Displayname, e.Login, e.UserID)
+			}
+			fmt.Printf("[ALERT][WATCH] %s of %s\n", e.Type, name)
+		}
+		for _, handler := range handlers {
+			handler(e)
+		}
+	}
+}
+
+// eventTx remembers the events appended within a transaction
+type eventTx struct {
+	storage.Tx
+	events []Event
+}
+
+func (tx *eventTx) AppendEvent(e Event) (uint64, error) {
+	id, err := tx.Tx.AppendEvent(e)
+	if err == nil {
+		e.ID = id
+		tx.events = append(tx.events, e)
+	}
+	return id, err
+}
+
+// update runs fn in a write transaction and passes the events it recorded to the handlers once committed
+func (t *Tracker) update(fn func(tx storage.Tx) error) error {
+	var events []Event
+	err := t.store.Update(func(tx storage.Tx) error {
+		etx := &eventTx{Tx: tx}
+		err := fn(etx)
+		events = etx.events
+		return err
+	})
+	if err == nil {
+		t.emit(events)
+	}
+	return err
+}
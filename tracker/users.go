@@ -0,0 +1,158 @@
+package tracker
+
+import (
+	"time"
+
+	"github.com/devinjdawson/tut/storage"
+)
+
+// UserDetail user profile info with the current relationship to the tracked channel
+type UserDetail struct {
+	ID              string        `json:"id"`
+	Login           string        `json:"login"`
+	Displayname     string        `json:"displayname"`
+	ProfileImageURL string        `json:"profileImageURL"`
+	Relationship    Relationship  `json:"relationship"`
+	Tags            []string      `json:"tags,omitempty"`
+	Notes           []Note        `json:"notes,omitempty"`
+	Labels          []Label       `json:"labels,omitempty"`
+	Roles           *Roles        `json:"roles,omitempty"`
+	Account         *AccountState `json:"account,omitempty"`
+}
+
+// Relationship between the tracked channel and a user
+type Relationship struct {
+	Follower      bool   `json:"follower"`
+	FollowedAt    string `json:"followedAt"`
+	UnfollowedAt  string `json:"unfollowedAt"`
+	RefollowedAt  string `json:"refollowedAt"`
+	Following     bool   `json:"following"`
+	FollowingAt   string `json:"followingAt"`
+	UnfollowingAt string `json:"unfollowingAt"`
+	RefollowingAt string `json:"refollowingAt"`
+}
+
+// GetUserProfile reads the stored profile of a user
+func GetUserProfile(tx storage.Tx, uid string) (map[string]string, bool) {
+	u, ok := tx.User(uid)
+	if !ok {
+		return nil, false
+	}
+	return storage.ParseUserProfile(u.User)
+}
+
+// FetchUser reads the stored profile of a user, fetching it from Twitch first when the profiles job didn't yet.
+// It records events, so event handlers must not call it.
+func (t *Tracker) FetchUser(uid string) (map[string]string, error) {
+	var profile map[string]string
+	var ok bool
+	t.store.View(func(tx storage.Tx) error {
+		profile, ok = GetUserProfile(tx, uid)
+		return nil
+	})
+	if ok {
+		return profile, nil
+	}
+
+	result, err := t.twitch.GetUser(uid)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(result.Response["user"])
+	err = t.update(func(tx storage.Tx) error {
+		return putUser(tx, uid, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile, _ = storage.ParseUserProfile(data)
+	return profile, nil
+}
+
+// putUser stores Helix user JSON fetched now and records profile fetches and changes
+func putUser(tx storage.Tx, uid string, data []byte) error {
+	oldProfile, hadProfile := GetUserProfile(tx, uid)
+
+	err := tx.PutUser(uid, storage.NewUserRecord(data))
+	if err != nil {
+		return err
+	}
+
+	profile, hasProfile := storage.ParseUserProfile(data)
+	if !hasProfile {
+		return nil
+	}
+
+	// Keep history of when profile was fetched and what has changed
+	e := Event{
+		Type:        storage.EventEnrichment,
+		UserID:      uid,
+		Login:       profile["login"],
+		Displayname: profile["display_name"],
+		At:          time.Now().UTC().Format(time.RFC3339),
+	}
+	if hadProfile {
+		changes := make(map[string]string)
+		for _, key := range []string{"login", "display_name", "profile_image_url"} {
+			if oldProfile[key] != profile[key] {
+				changes["old_"+key] = oldProfile[key]
+				changes["new_"+key] = profile[key]
+			}
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		e.Type = storage.EventProfile
+		e.Details = changes
+	}
+	return recordEvent(tx, e)
+}
+
+// GetUserDetail combines the stored profile with follow relationship of a user
+func GetUserDetail(tx storage.Tx, uid string) (UserDetail, bool) {
+	detail := UserDetail{ID: uid}
+	known := false
+
+	if profile, ok := GetUserProfile(tx, uid); ok {
+		detail.Login = profile["login"]
+		detail.Displayname = profile["display_name"]
+		detail.ProfileImageURL = profile["profile_image_url"]
+		known = true
+	}
+
+	detail.Tags = GetTags(tx, uid)
+	record := getNotesRecord(tx, uid)
+	detail.Notes, detail.Labels = record.Notes, record.Labels
+	detail.Roles = GetRoles(tx, uid)
+	detail.Account = GetAccount(tx, uid)
+	rel := &detail.Relationship
+	rel.FollowedAt = tx.Relation(storage.ListFollowers, uid)
+	rel.UnfollowedAt = tx.Relation(storage.ListUnfollowers, uid)
+	rel.FollowingAt = tx.Relation(storage.ListFollowing, uid)
+	rel.UnfollowingAt = tx.Relation(storage.ListUnfollowing, uid)
+	rel.Follower = rel.FollowedAt != ""
+	rel.Following = rel.FollowingAt != ""
+
+	// Follow again after an unfollow, followed time is the refollow time
+	if rel.Follower && rel.UnfollowedAt != "" {
+		rel.RefollowedAt = rel.FollowedAt
+	}
+	if rel.Following && rel.UnfollowingAt != "" {
+		rel.RefollowingAt = rel.FollowingAt
+	}
+
+	if rel.FollowedAt != "" || rel.UnfollowedAt != "" || rel.FollowingAt != "" || rel.UnfollowingAt != "" {
+		known = true
+	}
+	return detail, known
+}
+
+// needsProfile tells whether a user's profile still has to be fetched, imported users only have the profile of their export
+func needsProfile(tx storage.Tx, uid string) bool {
+	// The accounts job checks accounts Twitch stopped returning
+	if account := GetAccount(tx, uid); account != nil && account.Status != AccountRestored {
+		return false
+	}
+	u, ok := tx.User(uid)
+	return !ok || (u.User != nil && userCreatedAt(u.User) == "")
+}
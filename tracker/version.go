@@ -0,0 +1,4 @@
+package tracker
+
+// Version of TUT
+const Version = "1.3"
@@ -0,0 +1,385 @@
+// Package twitch calls the parts of the Twitch Helix API TUT needs: users, follows, streams, videos, and the
+// subscribers, VIPs, moderators and banned users of a channel.
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/gabs"
+)
+
+// Client of the Helix API
+type Client struct {
+	ClientID string
+	// OAuth token without "oauth:", optional
+	OAuth string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// OnResponse is called with every response, e.g. to watch the rate limit
+	OnResponse func(r Result)
+
+	// The rate limit is shared by every request of the client, also by concurrent ones
+	limitMu        sync.Mutex
+	limitRemaining int
+	limitReset     time.Time
+}
+
+// Result status and rate limit of a response, Response holds what a call returns besides its list
+type Result struct {
+	StatusCode     int
+	Response       map[string]string
+	Limit          int
+	LimitRemaining int
+	LimitReset     int64
+	// Scope the OAuth scope the request needed, empty if any token works. Twitch answers 401 without it.
+	Scope string
+}
+
+// RateLimited tells whether a request failed because the rate limit is used up
+func (r Result) RateLimited() bool {
+	return r.StatusCode != 200 && r.StatusCode != 0 && r.LimitRemaining == 0 && r.LimitReset > 0
+}
+
+// WaitForReset sleeps until the rate limit resets
+func (r Result) WaitForReset() {
+	time.Sleep(time.Unix(r.LimitReset, 0).Sub(time.Now()))
+}
+
+// Follow one follow relationship, UserID is the follower in followers and the followed channel in following
+type Follow struct {
+	UserID     string
+	FollowedAt string
+}
+
+// Stream a live stream or past broadcast, past broadcasts without stream ID get "video-<video ID>"
+type Stream struct {
+	ID        string
+	StartedAt string
+	EndedAt   string
+	Title     string
+	Category  string
+}
+
+// waitForLimit holds a request back until the rate limit resets when the last response said it is used up
+func (c *Client) waitForLimit() {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	if c.limitRemaining <= 0 && time.Now().Before(c.limitReset) {
+		time.Sleep(time.Until(c.limitReset))
+	}
+	c.limitRemaining--
+}
+
+// rememberLimit keeps the rate limit of a response for the next requests
+func (c *Client) rememberLimit(r Result) {
+	if r.LimitReset == 0 {
+		return
+	}
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	c.limitRemaining = r.LimitRemaining
+	c.limitReset = time.Unix(r.LimitReset, 0)
+}
+
+// get sends a GET request to Helix, a body is only read for 200
+func (c *Client) get(u string) (Result, *gabs.Container, error) {
+	return c.getScoped(u, "")
+}
+
+// getScoped sends a GET request that needs an OAuth scope to Helix
+func (c *Client) getScoped(u string, scope string) (Result, *gabs.Container, error) {
+	c.waitForLimit()
+	req, _ := http.NewRequest("GET", u, nil)
+	req.Header.Add("Client-ID", c.ClientID)
+	if len(c.OAuth) > 0 {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.OAuth))
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	header := resp.Header
+	result := Result{StatusCode: resp.StatusCode, Scope: scope}
+	result.Limit, _ = strconv.Atoi(header.Get("Ratelimit-Limit"))
+	result.LimitRemaining, _ = strconv.Atoi(header.Get("Ratelimit-Remaining"))
+	result.LimitReset, _ = strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64)
+	c.rememberLimit(result)
+	if c.OnResponse != nil {
+		c.OnResponse(result)
+	}
+
+	if resp.StatusCode != 200 {
+		return result, nil, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return result, nil, err
+	}
+	parsed, err := gabs.ParseJSON(body)
+	return result, parsed, err
+}
+
+// GetUserID finds the ID of a login, Response holds "id"
+func (c *Client) GetUserID(login string) (Result, error) {
+	result, parsed, err := c.get(fmt.Sprintf("https://api.twitch.tv/helix/users?login=%s", url.QueryEscape(login)))
+	if err != nil {
+		return result, err
+	}
+
+	if parsed != nil && parsed.ExistsP("data.id") {
+		userdata, _ := parsed.Path("data").Children()
+		firstuserdata, _ := userdata[0].ChildrenMap()
+		result.Response = map[string]string{"id": firstuserdata["id"].Data().(string)}
+		return result, nil
+	}
+	return result, errors.New("GetUserID: cannot get UserID from Twitch API, check your ClientID or username")
+}
+
+// GetUserName finds login and display name of a user, Response holds "login" and "displayname"
+func (c *Client) GetUserName(userID string) (Result, error) {
+	result, parsed, err := c.get(fmt.Sprintf("https://api.twitch.tv/helix/users?id=%s", url.QueryEscape(userID)))
+	if err != nil {
+		return result, err
+	}
+
+	if parsed != nil && parsed.ExistsP("data.login") {
+		userdata, _ := parsed.Path("data").Children()
+		firstuserdata, _ := userdata[0].ChildrenMap()
+		result.Response = map[string]string{"login": firstuserdata["login"].Data().(string), "displayname": firstuserdata["display_name"].Data().(string)}
+		return result, nil
+	}
+	return result, errors.New("GetUserName: cannot get username from Twitch API, check your ClientID or userID")
+}
+
+// GetUser fetches a user, Response holds the Helix user JSON as "user", none if the user doesn't exist any more
+func (c *Client) GetUser(userID string) (Result, error) {
+	result, parsed, err := c.get(fmt.Sprintf("https://api.twitch.tv/helix/users?id=%s", url.QueryEscape(userID)))
+	if err != nil {
+		return result, err
+	}
+
+	if parsed != nil && parsed.ExistsP("data.login") {
+		userdata, _ := parsed.Path("data").Children()
+		result.Response = map[string]string{"user": userdata[0].String()}
+		return result, nil
+	}
+	return result, errors.New("GetUser: cannot get user from Twitch API, check your ClientID or userID")
+}
+
+// GetFollowers reads a page of followers of userID, Response holds the cursor of the next page as "next"
+func (c *Client) GetFollowers(userID string, pagination string) (Result, []Follow, error) {
+	return c.getFollows(fmt.Sprintf("https://api.twitch.tv/helix/users/follows?to_id=%s&first=100&after=%s", url.QueryEscape(userID), url.QueryEscape(pagination)), "from_id")
+}
+
+// GetFollowing reads a page of channels userID follows, Response holds the cursor of the next page as "next"
+func (c *Client) GetFollowing(userID string, pagination string) (Result, []Follow, error) {
+	return c.getFollows(fmt.Sprintf("https://api.twitch.tv/helix/users/follows?from_id=%s&first=100&after=%s", url.QueryEscape(userID), url.QueryEscape(pagination)), "to_id")
+}
+
+// getFollows reads a page of follows, idField names the other side of the follow
+func (c *Client) getFollows(u string, idField string) (Result, []Follow, error) {
+	result, parsed, err := c.get(u)
+	if err != nil {
+		return result, nil, err
+	}
+	if parsed == nil {
+		return result, nil, errors.New("getFollows: cannot get follows from Twitch API")
+	}
+
+	var output []Follow
+	follows, err := parsed.Path("data").Children()
+	if err != nil {
+		return result, nil, err
+	}
+
+	nextPagination := ""
+	if parsed.Path("pagination.cursor").Data() != nil {
+		nextPagination = parsed.Path("pagination.cursor").Data().(string)
+	}
+
+	for _, child := range follows {
+		childdata, _ := child.ChildrenMap()
+		uid, _ := childdata[idField].Data().(string)
+		followAt, _ := childdata["followed_at"].Data().(string)
+		output = append(output, Follow{uid, followAt})
+	}
+	result.Response = map[string]string{"next": nextPagination}
+	return result, output, nil
+}
+
+// GetStreams finds the live stream of userID, none while offline
+func (c *Client) GetStreams(userID string) (Result, []Stream, error) {
+	result, parsed, err := c.get(fmt.Sprintf("https://api.twitch.tv/helix/streams?user_id=%s", url.QueryEscape(userID)))
+	if err != nil {
+		return result, nil, err
+	}
+	if parsed == nil {
+		return result, nil, errors.New("GetStreams: cannot get stream from Twitch API")
+	}
+
+	var output []Stream
+	streams, _ := parsed.Path("data").Children()
+	for _, child := range streams {
+		childdata, _ := child.ChildrenMap()
+		stream := Stream{}
+		stream.ID, _ = childdata["id"].Data().(string)
+		stream.StartedAt, _ = childdata["started_at"].Data().(string)
+		stream.Title, _ = childdata["title"].Data().(string)
+		if category, ok := childdata["game_name"]; ok {
+			stream.Category, _ = category.Data().(string)
+		}
+		output = append(output, stream)
+	}
+	return result, output, nil
+}
+
+// GetVideos reads the latest past broadcasts of userID
+func (c *Client) GetVideos(userID string) (Result, []Stream, error) {
+	result, parsed, err := c.get(fmt.Sprintf("https://api.twitch.tv/helix/videos?user_id=%s&type=archive&first=100", url.QueryEscape(userID)))
+	if err != nil {
+		return result, nil, err
+	}
+	if parsed == nil {
+		return result, nil, errors.New("GetVideos: cannot get videos from Twitch API")
+	}
+
+	var output []Stream
+	videos, _ := parsed.Path("data").Children()
+	for _, child := range videos {
+		childdata, _ := child.ChildrenMap()
+		stream := Stream{}
+		// Past broadcasts refer to their stream, older ones only have a video ID
+		if streamID, ok := childdata["stream_id"]; ok {
+			stream.ID, _ = streamID.Data().(string)
+		}
+		if stream.ID == "" {
+			videoID, _ := childdata["id"].Data().(string)
+			stream.ID = "video-" + videoID
+		}
+		stream.StartedAt, _ = childdata["created_at"].Data().(string)
+		stream.Title, _ = childdata["title"].Data().(string)
+		duration, _ := childdata["duration"].Data().(string)
+		startedAt, err := time.Parse(time.RFC3339, stream.StartedAt)
+		length, err2 := time.ParseDuration(duration)
+		if err == nil && err2 == nil {
+			stream.EndedAt = startedAt.Add(length).UTC().Format(time.RFC3339)
+		}
+		output = append(output, stream)
+	}
+	return result, output, nil
+}
+
+// Subscription a subscriber of a channel, Tier is 1000, 2000 or 3000
+type Subscription struct {
+	UserID string
+	Tier   string
+	Gift   bool
+}
+
+// Ban a user banned from the chat of a channel, ExpiresAt is empty for permanent bans
+type Ban struct {
+	UserID    string
+	ExpiresAt string
+	Reason    string
+}
+
+// GetSubscriptions reads a page of subscribers of broadcasterID, the token must be the broadcaster's with
+// channel:read:subscriptions. Response holds the cursor of the next page as "next".
+func (c *Client) GetSubscriptions(broadcasterID string, pagination string) (Result, []Subscription, error) {
+	result, users, err := c.getUsers(fmt.Sprintf("https://api.twitch.tv/helix/subscriptions?broadcaster_id=%s&first=100&after=%s", url.QueryEscape(broadcasterID), url.QueryEscape(pagination)), "channel:read:subscriptions")
+	var output []Subscription
+	for _, u := range users {
+		output = append(output, Subscription{u["user_id"], u["tier"], u["is_gift"] == "true"})
+	}
+	return result, output, err
+}
+
+// GetVIPs reads a page of VIPs of broadcasterID, needs channel:read:vips. Response holds "next".
+func (c *Client) GetVIPs(broadcasterID string, pagination string) (Result, []string, error) {
+	return c.getUserIDs(fmt.Sprintf("https://api.twitch.tv/helix/channels/vips?broadcaster_id=%s&first=100&after=%s", url.QueryEscape(broadcasterID), url.QueryEscape(pagination)), "channel:read:vips")
+}
+
+// GetModerators reads a page of moderators of broadcasterID, needs moderation:read. Response holds "next".
+func (c *Client) GetModerators(broadcasterID string, pagination string) (Result, []string, error) {
+	return c.getUserIDs(fmt.Sprintf("https://api.twitch.tv/helix/moderation/moderators?broadcaster_id=%s&first=100&after=%s", url.QueryEscape(broadcasterID), url.QueryEscape(pagination)), "moderation:read")
+}
+
+// GetBannedUsers reads a page of users banned or timed out in the chat of broadcasterID, needs moderation:read.
+// Response holds "next".
+func (c *Client) GetBannedUsers(broadcasterID string, pagination string) (Result, []Ban, error) {
+	result, users, err := c.getUsers(fmt.Sprintf("https://api.twitch.tv/helix/moderation/banned?broadcaster_id=%s&first=100&after=%s", url.QueryEscape(broadcasterID), url.QueryEscape(pagination)), "moderation:read")
+	var output []Ban
+	for _, u := range users {
+		output = append(output, Ban{u["user_id"], u["expires_at"], u["reason"]})
+	}
+	return result, output, err
+}
+
+// GetBan reads whether userID is banned or timed out in the chat of broadcasterID, needs moderation:read. The
+// Ban is nil when they aren't.
+func (c *Client) GetBan(broadcasterID string, userID string) (Result, *Ban, error) {
+	result, users, err := c.getUsers(fmt.Sprintf("https://api.twitch.tv/helix/moderation/banned?broadcaster_id=%s&user_id=%s", url.QueryEscape(broadcasterID), url.QueryEscape(userID)), "moderation:read")
+	for _, u := range users {
+		if u["user_id"] == userID {
+			return result, &Ban{u["user_id"], u["expires_at"], u["reason"]}, nil
+		}
+	}
+	return result, nil, err
+}
+
+// getUserIDs reads a page of users, returning their IDs
+func (c *Client) getUserIDs(u string, scope string) (Result, []string, error) {
+	result, users, err := c.getUsers(u, scope)
+	var output []string
+	for _, user := range users {
+		output = append(output, user["user_id"])
+	}
+	return result, output, err
+}
+
+// getUsers reads a page of a list of users that needs scope, with the text and bool fields of every user
+func (c *Client) getUsers(u string, scope string) (Result, []map[string]string, error) {
+	result, parsed, err := c.getScoped(u, scope)
+	if err != nil {
+		return result, nil, err
+	}
+	if parsed == nil {
+		return result, nil, fmt.Errorf("getUsers: Twitch answered %d, the token may lack %s", result.StatusCode, scope)
+	}
+
+	var output []map[string]string
+	users, _ := parsed.Path("data").Children()
+	for _, child := range users {
+		childdata, _ := child.ChildrenMap()
+		user := make(map[string]string)
+		for k, v := range childdata {
+			switch value := v.Data().(type) {
+			case string:
+				user[k] = value
+			case bool:
+				user[k] = strconv.FormatBool(value)
+			}
+		}
+		output = append(output, user)
+	}
+
+	nextPagination := ""
+	if parsed.Path("pagination.cursor").Data() != nil {
+		nextPagination, _ = parsed.Path("pagination.cursor").Data().(string)
+	}
+	result.Response = map[string]string{"next": nextPagination}
+	return result, output, nil
+}
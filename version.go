@@ -1,3 +0,0 @@
-package main
-
-const version = "1.3"